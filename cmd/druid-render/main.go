@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
+	"github.com/BinaryOmen/druid-operator/pkg/controller/druid"
+	"github.com/BinaryOmen/druid-operator/pkg/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// druid-render prints the manifests the operator creates for the Druid CRs of a file, without a cluster
+//
+//	druid-render [-namespace druid] deploy/crds/cr.yaml
+func main() {
+	namespace := flag.String("namespace", "default", "namespace of Druid CRs without one")
+	ingressAPIVersion := flag.String("ingress-api-version", capabilities.IngressV1, "Ingress apiVersion served by the target cluster")
+	topologySpread := flag.Bool("topology-spread-constraints", true, "Target cluster serves topologySpreadConstraints, false before kubernetes 1.18")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-namespace ns] [-ingress-api-version version] [-topology-spread-constraints=false] <druid-cr.yaml | ->\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var b []byte
+	var err error
+	if flag.Arg(0) == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	caps := capabilities.Default()
+	caps.IngressAPIVersion = *ingressAPIVersion
+	caps.TopologySpreadConstraints = *topologySpread
+
+	out := &bytes.Buffer{}
+	for _, doc := range bytes.Split(b, []byte("\n---")) {
+		doc = bytes.TrimPrefix(bytes.TrimSpace(doc), []byte("---"))
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		c := &binaryomenv1alpha1.Druid{}
+		if err = yaml.Unmarshal(doc, c); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if c.Kind != "Druid" {
+			continue
+		}
+		if c.Namespace == "" {
+			c.Namespace = *namespace
+		}
+
+		if err = render(out, c, caps); err != nil {
+			fmt.Fprintf(os.Stderr, "Druid [%s]: %v\n", c.Name, err)
+			os.Exit(1)
+		}
+	}
+	os.Stdout.Write(out.Bytes())
+}
+
+func render(out *bytes.Buffer, c *binaryomenv1alpha1.Druid, caps *capabilities.Capabilities) error {
+	validator := validation.Validator{}
+	validator.Validate(c)
+	if validator.WarningMessage != "" {
+		fmt.Fprint(os.Stderr, validator.WarningMessage)
+	}
+	if !validator.Validated {
+		return fmt.Errorf("validation failed\n%s", validator.ErrorMessage)
+	}
+
+	objects, err := druid.RenderManifests(c, caps)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		b, err := marshal(obj)
+		if err != nil {
+			return err
+		}
+		out.WriteString("---\n")
+		out.Write(b)
+	}
+	return nil
+}
+
+// marshal drops the empty status and creationTimestamp the typed objects carry, they only add noise to diffs
+func marshal(obj runtime.Object) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	delete(u, "status")
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u, "spec", "template", "metadata", "creationTimestamp")
+	if templates, ok, _ := unstructured.NestedSlice(u, "spec", "volumeClaimTemplates"); ok {
+		for _, t := range templates {
+			if m, ok := t.(map[string]interface{}); ok {
+				delete(m, "status")
+				unstructured.RemoveNestedField(m, "metadata", "creationTimestamp")
+			}
+		}
+		unstructured.SetNestedSlice(u, templates, "spec", "volumeClaimTemplates")
+	}
+	return yaml.Marshal(u)
+}
@@ -13,6 +13,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/BinaryOmen/druid-operator/pkg/apis"
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
 	"github.com/BinaryOmen/druid-operator/pkg/controller"
 	"github.com/BinaryOmen/druid-operator/version"
 
@@ -121,8 +122,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Discover the optional apis served by the cluster
+	caps, err := capabilities.Discover(cfg)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	log.Info("Discovered cluster capabilities",
+		"IngressAPIVersion", caps.IngressAPIVersion,
+		"HTTPRouteAPIVersion", caps.HTTPRouteAPIVersion,
+		"RouteAPIVersion", caps.RouteAPIVersion,
+		"ServiceMonitorAPIVersion", caps.ServiceMonitorAPIVersion,
+		"PodMonitorAPIVersion", caps.PodMonitorAPIVersion,
+		"TopologySpreadConstraints", caps.TopologySpreadConstraints)
+
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr); err != nil {
+	if err := controller.AddToManager(mgr, caps); err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
@@ -2,9 +2,85 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// DiscoveryZookeeper announces nodes and elects leaders through zookeeper
+	DiscoveryZookeeper = "zookeeper"
+	// DiscoveryKubernetes announces nodes through pod labels and elects leaders through configmaps,
+	// using the druid-kubernetes-extensions
+	DiscoveryKubernetes = "kubernetes"
+)
+
+const (
+	// ZookeeperExternal uses the zookeeper configured in CommonRuntimeProperties
+	ZookeeperExternal = "external"
+	// ZookeeperManaged runs a zookeeper ensemble owned by the Druid CR
+	ZookeeperManaged = "managed"
+)
+
+const (
+	// VolumeExpansionResizing waits for the storage provider to grow the volume
+	VolumeExpansionResizing = "Resizing"
+	// VolumeExpansionFileSystemResizePending waits for the kubelet to grow the filesystem of a mounted volume
+	VolumeExpansionFileSystemResizePending = "FileSystemResizePending"
+	// VolumeExpansionCompleted reports a claim whose capacity matches the request
+	VolumeExpansionCompleted = "Completed"
+	// VolumeExpansionUnsupported reports a claim whose StorageClass does not allow volume expansion
+	VolumeExpansionUnsupported = "Unsupported"
+)
+
+const (
+	// PVCRetain keeps the claims of a statefulset until they are deleted by hand
+	PVCRetain = "Retain"
+	// PVCDeleteOnScaleDown deletes the claims of removed replicas once scaled down, and every claim on cluster deletion
+	PVCDeleteOnScaleDown = "DeleteOnScaleDown"
+	// PVCDeleteOnClusterDelete deletes the claims of a statefulset when the Druid CR is deleted
+	PVCDeleteOnClusterDelete = "DeleteOnClusterDelete"
+)
+
+const (
+	// TeardownSuspendingSupervisors stops ingestion before the cluster goes away
+	TeardownSuspendingSupervisors = "SuspendingSupervisors"
+	// TeardownWaitingForTasks lets running tasks publish their segments
+	TeardownWaitingForTasks = "WaitingForTasks"
+	// TeardownScalingDown scales the nodes to zero in reverse of the prescribed order
+	TeardownScalingDown = "ScalingDown"
+)
+
+const (
+	// HibernationHibernating scales the nodes down after suspending the supervisors
+	HibernationHibernating = "Hibernating"
+	// HibernationHibernated reports a cluster without any druid pod
+	HibernationHibernated = "Hibernated"
+	// HibernationResuming restores the nodes in the prescribed order before resuming the supervisors
+	HibernationResuming = "Resuming"
+)
+
+const (
+	// DriftCorrect overwrites the live resources edited outside the operator, and reports them
+	DriftCorrect = "Correct"
+	// DriftReport only reports the live resources edited outside the operator, changes of the Druid spec are still applied
+	DriftReport = "Report"
+)
+
+const (
+	// PortPlaintext names the http port of a node
+	PortPlaintext = "plaintext"
+	// PortTLS names the https port of a node
+	PortTLS = "tls"
+	// PortMetrics is the name of the port the prometheus emitter serves metrics on
+	PortMetrics = "metrics"
+
+	// MonitorService scrapes the node services through a ServiceMonitor
+	MonitorService = "ServiceMonitor"
+	// MonitorPod scrapes the node pods through a PodMonitor
+	MonitorPod = "PodMonitor"
+)
+
 // DruidSpec represents the druid spec.
 // Scope: Cluster Level
 type DruidSpec struct {
@@ -32,6 +108,26 @@ type DruidSpec struct {
 	Env []v1.EnvVar `json:"env,omitempty"`
 	// Required: Path to mount commonruntimeproperties
 	CommonConfigMountPath string `json:"commonConfigMountPath"`
+	// Optional: Extensions loaded by all nodes
+	Extensions *DruidExtensions `json:"extensions,omitempty"`
+	// Optional: Discovery can be zookeeper or kubernetes, defaults to zookeeper
+	Discovery string `json:"discovery,omitempty"`
+	// Optional: Zookeeper used by the cluster, defaults to the external zookeeper in CommonRuntimeProperties
+	Zookeeper *DruidZookeeper `json:"zookeeper,omitempty"`
+	// Optional: How long the deletion of the cluster waits for running tasks after suspending supervisors, defaults to 10m
+	TeardownTimeout *metav1.Duration `json:"teardownTimeout,omitempty"`
+	// Optional: Suspends the supervisors and scales every node to zero, keeping claims and configmaps
+	Suspended bool `json:"suspended,omitempty"`
+	// Optional: Correct or Report resources edited outside the operator, defaults to Correct
+	DriftPolicy string `json:"driftPolicy,omitempty"`
+	// Optional: TLS between the druid nodes
+	TLS *DruidTLS `json:"tls,omitempty"`
+	// Optional: basic-security authentication with operator generated passwords
+	Authentication *DruidAuthentication `json:"authentication,omitempty"`
+	// Optional: NetworkPolicies restricting the traffic between druid node types
+	NetworkPolicy *DruidNetworkPolicy `json:"networkPolicy,omitempty"`
+	// Optional: Prometheus metrics of the druid nodes
+	Monitoring *DruidMonitoring `json:"monitoring,omitempty"`
 }
 
 // NodeSpec specific to all nodes
@@ -50,6 +146,12 @@ type NodeSpec struct {
 	Service DruidService `json:"service"`
 	// Optional: Ingress
 	Ingress DruidIngress `json:"ingress,omitempty"`
+	// Optional: Gateway API HTTPRoute attaching the node to a Gateway, for routers and brokers
+	Gateway *DruidGateway `json:"gateway,omitempty"`
+	// Optional: OpenShift Route exposing the node, eg the router console
+	Route *DruidRoute `json:"route,omitempty"`
+	// Optional: Sources allowed to reach the node besides the druid nodes, with NetworkPolicy enabled
+	NetworkPolicyIngress []networkingv1.NetworkPolicyPeer `json:"networkPolicyIngress,omitempty"`
 	// Optional: JVM Options
 	JvmOptions string `json:"jvm.options,omitempty"`
 	// Optional: Log4jConfig
@@ -76,12 +178,80 @@ type NodeSpec struct {
 	VolumeClaimTemplates []v1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
 	// Optional: Pod Disruption Budget
 	PodDisruptionBudget bool `json:"podDisruptionBudget,omitempty"`
+	// Optional: Skip pulling the cluster extensions, the node only loads the extensions bundled with the image
+	SkipExtensions bool `json:"skipExtensions,omitempty"`
+	// Optional: Derive heap, direct memory and processing settings from Resources
+	MemoryModel *MemoryModel `json:"memoryModel,omitempty"`
+	// Optional: Tier of historical nodes, generates druid.server.tier
+	Tier string `json:"tier,omitempty"`
+	// Optional: Priority of the historical tier, generates druid.server.priority
+	TierPriority *int32 `json:"tierPriority,omitempty"`
+	// Optional: Topology key the pods are spread across, eg topology.kubernetes.io/zone, through preferred pod
+	// anti-affinity before kubernetes 1.18
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// Optional: Segment cache of historical nodes, sized from a volume claim template
+	SegmentCache *SegmentCache `json:"segmentCache,omitempty"`
+	// Optional: What happens to the claims of the statefulset on scale down and cluster deletion, defaults to Retain
+	PVCRetentionPolicy string `json:"pvcRetentionPolicy,omitempty"`
+}
+
+// MemoryModel sizes the jvm and processing buffers of a node from its container resources,
+// defaults follow the druid basic cluster tuning guide for the node type
+type MemoryModel struct {
+	// Optional: Heap size, defaults to a share of the container memory depending on the node type
+	Heap *resource.Quantity `json:"heap,omitempty"`
+	// Optional: Size of a processing buffer, defaults to the direct memory share split across buffers, at most 1Gi
+	BufferSize *resource.Quantity `json:"bufferSize,omitempty"`
+	// Optional: Processing threads, defaults to the container cpus minus one
+	NumThreads int32 `json:"numThreads,omitempty"`
+	// Optional: Merge buffers, defaults to a quarter of the processing threads, at least two
+	NumMergeBuffers int32 `json:"numMergeBuffers,omitempty"`
+}
+
+// SegmentCache generates druid.segmentCache.locations and druid.server.maxSize from the storage
+// requested by a volume claim template
+type SegmentCache struct {
+	// Required: Name of the volume claim template holding the segment cache
+	VolumeClaimTemplate string `json:"volumeClaimTemplate"`
+	// Required: Path of the segment cache, the claim is mounted there unless VolumeMounts already mount it
+	MountPath string `json:"mountPath"`
+	// Optional: Percentage of the claim kept free for the filesystem and temporary files, defaults to 10
+	HeadroomPercent *int32 `json:"headroomPercent,omitempty"`
 }
 
 type DruidService struct {
 	Port       int32          `json:"port"`
 	TargetPort int32          `json:"targetPort"`
 	Type       v1.ServiceType `json:"type,omitempty"`
+	// Optional: Named ports of the service and container, replacing Port and TargetPort
+	Ports []DruidServicePort `json:"ports,omitempty"`
+	// Optional: Annotations, eg of the cloud load balancer
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Optional: Labels added to the app, type and name labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Optional: Client ranges allowed by a LoadBalancer service
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// Optional: IP requested for a LoadBalancer service
+	LoadBalancerIP string `json:"loadBalancerIP,omitempty"`
+	// Optional: Cluster or Local, for NodePort and LoadBalancer services
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	// Optional: None or ClientIP, eg to keep a console session on one router
+	SessionAffinity v1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// Optional: Timeout of the ClientIP session affinity
+	SessionAffinityConfig *v1.SessionAffinityConfig `json:"sessionAffinityConfig,omitempty"`
+}
+
+// DruidServicePort is a named port of a node service and container, the plaintext and tls ports
+// set the druid.plaintextPort and druid.tlsPort properties
+type DruidServicePort struct {
+	// Required: Name, plaintext, tls or any other port name eg metrics
+	Name string `json:"name"`
+	// Required: Port of the service
+	Port int32 `json:"port"`
+	// Required: Port of the container
+	TargetPort int32 `json:"targetPort"`
+	// Optional: Protocol, defaults to TCP
+	Protocol v1.Protocol `json:"protocol,omitempty"`
 }
 
 type DruidIngress struct {
@@ -93,10 +263,262 @@ type DruidIngress struct {
 	TLSEnabled    bool              `json:"tlsEnabled,omitempty"`
 	TLSSecretName string            `json:"tlsSecretName,omitempty"`
 	TargetPort    string            `json:"targetPort,omitempty"`
+	// Optional: IngressClass of the ingress controller serving the Ingress
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// Optional: Exact, Prefix or ImplementationSpecific, defaults to ImplementationSpecific, only set on networking.k8s.io/v1
+	PathType string `json:"pathType,omitempty"`
+	// Optional: Hosts and paths routed to the node, Hostname and Path are ignored when set
+	Rules []DruidIngressRule `json:"rules,omitempty"`
+}
+
+// DruidIngressRule routes the paths of a host to the node
+type DruidIngressRule struct {
+	// Required: Host
+	Host string `json:"host"`
+	// Required: Paths of the host
+	Paths []DruidIngressPath `json:"paths"`
+}
+
+// DruidIngressPath routes a path to a named Service port of the node
+type DruidIngressPath struct {
+	// Required: Path
+	Path string `json:"path"`
+	// Optional: Exact, Prefix or ImplementationSpecific, defaults to the Ingress PathType
+	PathType string `json:"pathType,omitempty"`
+	// Optional: Service port name, defaults to the Ingress TargetPort
+	TargetPort string `json:"targetPort,omitempty"`
+}
+
+// DruidGateway renders an HTTPRoute from the parent Gateway to the node Service
+type DruidGateway struct {
+	// Required: Gateway the route attaches to
+	ParentRef DruidGatewayParentRef `json:"parentRef"`
+	// Optional: Hostnames matched by the route, defaults to the hostnames of the Gateway listener
+	Hostnames []string `json:"hostnames,omitempty"`
+	// Optional: Requests matched by the route, defaults to the / path prefix
+	Matches []DruidHTTPRouteMatch `json:"matches,omitempty"`
+	// Optional: Headers set, added or removed on requests before they reach the node
+	RequestHeaders *DruidHTTPHeaderFilter `json:"requestHeaders,omitempty"`
+	// Optional: Headers set, added or removed on responses of the node
+	ResponseHeaders *DruidHTTPHeaderFilter `json:"responseHeaders,omitempty"`
+	// Optional: Service port name, defaults to the http port of the node
+	TargetPort string `json:"targetPort,omitempty"`
+}
+
+// DruidGatewayParentRef references a Gateway, and optionally one of its listeners
+type DruidGatewayParentRef struct {
+	// Required: Gateway name
+	Name string `json:"name"`
+	// Optional: Gateway namespace, defaults to the Druid CR namespace
+	Namespace string `json:"namespace,omitempty"`
+	// Optional: Listener of the Gateway
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// DruidHTTPRouteMatch matches requests by path and headers
+type DruidHTTPRouteMatch struct {
+	// Optional: Path, defaults to /
+	Path string `json:"path,omitempty"`
+	// Optional: Exact, PathPrefix or RegularExpression, defaults to PathPrefix
+	PathType string `json:"pathType,omitempty"`
+	// Optional: Headers with exact values the requests must carry
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DruidHTTPHeaderFilter modifies http headers
+type DruidHTTPHeaderFilter struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// DruidRoute renders an OpenShift Route to the node Service
+type DruidRoute struct {
+	// Optional: Host, defaults to the host generated by the OpenShift router
+	Host string `json:"host,omitempty"`
+	// Optional: Path
+	Path string `json:"path,omitempty"`
+	// Optional: Service port name, defaults to the http port of the node
+	TargetPort string `json:"targetPort,omitempty"`
+	// Optional: Annotations, eg of the OpenShift router
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Optional: TLS terminated by the OpenShift router, the route is plain http without it
+	TLS *DruidRouteTLS `json:"tls,omitempty"`
+}
+
+// DruidRouteTLS defines where the OpenShift router terminates TLS
+type DruidRouteTLS struct {
+	// Required: edge, passthrough or reencrypt
+	Termination string `json:"termination"`
+	// Optional: None, Allow or Redirect plain http requests of edge routes
+	InsecureEdgeTerminationPolicy string `json:"insecureEdgeTerminationPolicy,omitempty"`
+	// Optional: PEM CA the OpenShift router validates the node certificate with, for reencrypt routes
+	DestinationCACertificate string `json:"destinationCACertificate,omitempty"`
+}
+
+// DruidTLS secures the traffic between druid nodes, with certificates issued by the operator CA
+// or read from an existing Secret
+type DruidTLS struct {
+	// Optional: Secret holding ca.crt, tls.crt and tls.key, eg issued by cert-manager, shared by every node
+	SecretName string `json:"secretName,omitempty"`
+	// Optional: Validity of the operator issued node certificates, defaults to 8760h
+	Validity *metav1.Duration `json:"validity,omitempty"`
+	// Optional: Node certificates are reissued when they expire within RenewBefore, defaults to 720h or a third of Validity
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+	// Required: Image with openssl and keytool building the keystores, the druid image does not ship openssl
+	Image string `json:"image"`
+}
+
+// DruidMonitoring configures the prometheus-emitter extension and the prometheus-operator monitor scraping it,
+// the extension is not bundled with druid and shall be pulled through Extensions or be part of the image
+type DruidMonitoring struct {
+	// Required: Enables the prometheus emitter and the monitor
+	Enabled bool `json:"enabled"`
+	// Optional: Port the emitter serves metrics on, defaults to 9000
+	Port int32 `json:"port,omitempty"`
+	// Optional: Prefix of the metric names, defaults to druid
+	Namespace string `json:"namespace,omitempty"`
+	// Optional: ServiceMonitor or PodMonitor, defaults to ServiceMonitor
+	Kind string `json:"kind,omitempty"`
+	// Optional: Scrape interval, defaults to the prometheus one
+	Interval string `json:"interval,omitempty"`
+	// Optional: Labels of the monitor, eg selected by the prometheus serviceMonitorSelector
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// DruidNetworkPolicy restricts the ingress of every node to the node types druid sends requests from,
+// egress to the metadata store and deep storage is left open
+type DruidNetworkPolicy struct {
+	// Required: Enables the NetworkPolicies
+	Enabled bool `json:"enabled"`
+	// Optional: Sources allowed to reach every node, eg a prometheus namespace
+	Ingress []networkingv1.NetworkPolicyPeer `json:"ingress,omitempty"`
+	// Optional: Sources allowed to scrape the metrics port of every node with Monitoring enabled, eg the prometheus pods
+	Monitoring []networkingv1.NetworkPolicyPeer `json:"monitoring,omitempty"`
+}
+
+// DruidAuthentication enables druid-basic-security, the admin and internal client passwords are generated
+// into a Secret owned by the Druid CR
+type DruidAuthentication struct {
+	// Required: Enables the basic authenticator, escalator and authorizer
+	Enabled bool `json:"enabled"`
+	// Optional: Name of the authenticator and authorizer, defaults to basic
+	Name string `json:"name,omitempty"`
+}
+
+// DruidExtensions computes druid.extensions.loadList and pulls the extensions missing from the image
+type DruidExtensions struct {
+	// Optional: Extensions bundled with the image, merged with the loadList in CommonRuntimeProperties
+	LoadList []string `json:"loadList,omitempty"`
+	// Optional: Maven coordinates fetched with pull-deps, eg org.apache.druid.extensions.contrib:druid-redis-cache:0.16.0-incubating
+	Coordinates []string `json:"coordinates,omitempty"`
+	// Optional: Image running pull-deps, defaults to the cluster image
+	Image string `json:"image,omitempty"`
+	// Optional: Path the pulled extensions are mounted at, defaults to /opt/druid/extensions-pulled
+	MountPath string `json:"mountPath,omitempty"`
+	// Optional: Volume the extensions are pulled into, defaults to an emptyDir.
+	// Pulls are skipped when the volume already holds the same coordinates.
+	Volume *v1.VolumeSource `json:"volume,omitempty"`
+}
+
+// DruidZookeeper describes the zookeeper ensemble used by the cluster
+type DruidZookeeper struct {
+	// Required: Type can be external or managed
+	Type string `json:"type"`
+	// Optional: Image of the managed ensemble, defaults to zookeeper:3.5
+	Image string `json:"image,omitempty"`
+	// Optional: Replicas of the managed ensemble, defaults to 1
+	Replicas int32 `json:"replicas,omitempty"`
+	// Optional: Resources of the managed ensemble
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// Optional: Storage claimed by each member, data is kept in an emptyDir when unset
+	Storage *v1.PersistentVolumeClaimSpec `json:"storage,omitempty"`
 }
 
 // DruidStatus defines the observed state of Druid
 type DruidStatus struct {
+	// Zookeeper reports the managed zookeeper ensemble
+	Zookeeper *ZookeeperStatus `json:"zookeeper,omitempty"`
+	// Tiers lists the historical tiers retention rules can load segments into
+	Tiers []TierStatus `json:"tiers,omitempty"`
+	// VolumeExpansions tracks the claims being resized to the storage requested by volumeClaimTemplates
+	VolumeExpansions []VolumeExpansionStatus `json:"volumeExpansions,omitempty"`
+	// Teardown reports the progress of the cluster deletion
+	Teardown *TeardownStatus `json:"teardown,omitempty"`
+	// Hibernation reports a suspended cluster, or one being resumed
+	Hibernation *HibernationStatus `json:"hibernation,omitempty"`
+	// Paused reports a cluster the operator does not write to, see the druid.binaryomen.org/paused annotation
+	Paused *PausedStatus `json:"paused,omitempty"`
+	// Drift lists the resources edited outside the operator since it rendered them
+	Drift []DriftStatus `json:"drift,omitempty"`
+	// Plan lists what the operator would change, see the druid.binaryomen.org/plan annotation
+	Plan *PlanStatus `json:"plan,omitempty"`
+	// CredentialsSecret names the Secret holding the admin and internal client passwords
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// PlanStatus defines the writes and restarts the operator would perform for the current spec
+type PlanStatus struct {
+	Request    string      `json:"request"`
+	ComputedAt metav1.Time `json:"computedAt"`
+	Operations []string    `json:"operations,omitempty"`
+	Restarts   []string    `json:"restarts,omitempty"`
+}
+
+// DriftStatus defines the fields of a resource whose live value differs from the rendered one
+type DriftStatus struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Fields []string    `json:"fields"`
+	Since  metav1.Time `json:"since"`
+}
+
+// PausedStatus defines who paused the cluster, since when, and the writes the operator skipped
+type PausedStatus struct {
+	By    string      `json:"by,omitempty"`
+	Since metav1.Time `json:"since"`
+	Drift []string    `json:"drift,omitempty"`
+}
+
+// HibernationStatus defines the state recorded when suspending the cluster and restored on resume
+type HibernationStatus struct {
+	Phase    string           `json:"phase"`
+	Replicas map[string]int32 `json:"replicas"`
+	// SpecReplicas are the replicas of the spec when hibernating, the spec wins on resume when they changed
+	SpecReplicas         map[string]int32 `json:"specReplicas,omitempty"`
+	SuspendedSupervisors []string         `json:"suspendedSupervisors,omitempty"`
+	// SupervisorsSuspended reports every supervisor suspended, the nodes are only scaled down from then
+	SupervisorsSuspended bool `json:"supervisorsSuspended,omitempty"`
+}
+
+// TeardownStatus defines the progress of the cluster deletion
+type TeardownStatus struct {
+	Phase        string `json:"phase"`
+	RunningTasks int32  `json:"runningTasks"`
+}
+
+// VolumeExpansionStatus defines the progress of a persistent volume claim expansion
+type VolumeExpansionStatus struct {
+	StatefulSet string `json:"statefulSet"`
+	Claim       string `json:"claim"`
+	Requested   string `json:"requested"`
+	Capacity    string `json:"capacity,omitempty"`
+	Phase       string `json:"phase"`
+}
+
+// TierStatus defines the historical nodes serving a tier
+type TierStatus struct {
+	Name     string   `json:"name"`
+	Priority int32    `json:"priority"`
+	Nodes    []string `json:"nodes"`
+	Replicas int32    `json:"replicas"`
+}
+
+// ZookeeperStatus defines the observed state of the managed zookeeper
+type ZookeeperStatus struct {
+	ConnectString string `json:"connectString"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
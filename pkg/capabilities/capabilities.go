@@ -0,0 +1,143 @@
+package capabilities
+
+import (
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// IngressV1 is served from kubernetes 1.19
+	IngressV1 = "networking.k8s.io/v1"
+	// IngressV1beta1 is served from kubernetes 1.14 to 1.21
+	IngressV1beta1 = "networking.k8s.io/v1beta1"
+	// IngressExtensions is served up to kubernetes 1.21
+	IngressExtensions = "extensions/v1beta1"
+	// HTTPRouteV1 is served by the Gateway API CRDs from v1.0
+	HTTPRouteV1 = "gateway.networking.k8s.io/v1"
+	// HTTPRouteV1beta1 is served by the Gateway API CRDs from v0.8
+	HTTPRouteV1beta1 = "gateway.networking.k8s.io/v1beta1"
+	// RouteV1 is served by OpenShift
+	RouteV1 = "route.openshift.io/v1"
+	// MonitoringV1 is served by the prometheus-operator CRDs
+	MonitoringV1 = "monitoring.coreos.com/v1"
+)
+
+// Capabilities lists the optional apis served by the cluster the operator runs in
+type Capabilities struct {
+	// IngressAPIVersion is the most recent Ingress api served
+	IngressAPIVersion string
+	// HTTPRouteAPIVersion is the most recent HTTPRoute api served, empty without the Gateway API CRDs
+	HTTPRouteAPIVersion string
+	// RouteAPIVersion is the OpenShift Route api, empty outside OpenShift
+	RouteAPIVersion string
+	// ServiceMonitorAPIVersion is the prometheus-operator ServiceMonitor api, empty without its CRDs
+	ServiceMonitorAPIVersion string
+	// PodMonitorAPIVersion is the prometheus-operator PodMonitor api, empty without its CRDs
+	PodMonitorAPIVersion string
+	// TopologySpreadConstraints reports the pod field enabled by default, from kubernetes 1.18, older api servers
+	// drop it unless the EvenPodsSpread feature gate is on
+	TopologySpreadConstraints bool
+}
+
+// Default assumes a recent cluster, it is used when the cluster is not reachable, eg to render manifests
+func Default() *Capabilities {
+	return &Capabilities{
+		IngressAPIVersion:         IngressV1,
+		HTTPRouteAPIVersion:       HTTPRouteV1,
+		RouteAPIVersion:           RouteV1,
+		ServiceMonitorAPIVersion:  MonitoringV1,
+		PodMonitorAPIVersion:      MonitoringV1,
+		TopologySpreadConstraints: true,
+	}
+}
+
+// Discover queries the api server for the optional apis
+func Discover(cfg *rest.Config) (*Capabilities, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &Capabilities{}
+	if caps.IngressAPIVersion, err = firstServed(dc, "Ingress", IngressV1, IngressV1beta1, IngressExtensions); err != nil {
+		return nil, err
+	}
+	if caps.HTTPRouteAPIVersion, err = firstServed(dc, "HTTPRoute", HTTPRouteV1, HTTPRouteV1beta1); err != nil {
+		return nil, err
+	}
+	if caps.RouteAPIVersion, err = firstServed(dc, "Route", RouteV1); err != nil {
+		return nil, err
+	}
+	if caps.ServiceMonitorAPIVersion, err = firstServed(dc, "ServiceMonitor", MonitoringV1); err != nil {
+		return nil, err
+	}
+	if caps.PodMonitorAPIVersion, err = firstServed(dc, "PodMonitor", MonitoringV1); err != nil {
+		return nil, err
+	}
+	if caps.TopologySpreadConstraints, err = servesTopologySpreadConstraints(dc); err != nil {
+		return nil, err
+	}
+	return caps, nil
+}
+
+// firstServed returns the first of the versions serving kind, or an empty string
+func firstServed(dc discovery.DiscoveryInterface, kind string, versions ...string) (string, error) {
+	for _, version := range versions {
+		ok, err := servesKind(dc, version, kind)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return version, nil
+		}
+	}
+	return "", nil
+}
+
+func servesKind(dc discovery.DiscoveryInterface, groupVersion string, kind string) (bool, error) {
+	resources, err := dc.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind == kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// servesTopologySpreadConstraints checks the server runs kubernetes 1.18 or later
+func servesTopologySpreadConstraints(dc discovery.DiscoveryInterface) (bool, error) {
+	v, err := dc.ServerVersion()
+	if err != nil {
+		return false, err
+	}
+	major, minor := leadingInt(v.Major), leadingInt(v.Minor)
+	return major > 1 || (major == 1 && minor >= 18), nil
+}
+
+// leadingInt parses the digits a version part starts with, providers suffix the minor version, eg 16+
+func leadingInt(s string) int {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i >= 0 {
+		s = s[:i]
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// MonitorAPIVersion returns the api serving the ServiceMonitor or PodMonitor kind, or an empty string
+func (c *Capabilities) MonitorAPIVersion(kind string) string {
+	if kind == "PodMonitor" {
+		return c.PodMonitorAPIVersion
+	}
+	return c.ServiceMonitorAPIVersion
+}
@@ -1,16 +1,17 @@
 package controller
 
 import (
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager) error
+var AddToManagerFuncs []func(manager.Manager, *capabilities.Capabilities) error
 
-// AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager) error {
+// AddToManager adds all Controllers to the Manager, caps lists the optional apis served by the cluster
+func AddToManager(m manager.Manager, caps *capabilities.Capabilities) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m); err != nil {
+		if err := f(m, caps); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,62 @@
+package druid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const passwordBytes = 24
+
+// reconcileAuthentication shall generate the admin and internal client passwords once, druid only reads
+// them when it initializes the metadata store so they are never rotated by the operator
+func (r *ReconcileDruid) reconcileAuthentication(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	if !nodes.IsAuthenticationEnabled(c) {
+		c.Status.CredentialsSecret = ""
+		return nil
+	}
+
+	cur := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodes.MakeCredentialsSecretName(c), Namespace: c.Namespace}, cur)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		admin, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		internal, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		if err := r.createSecret(c, nodes.MakeCredentialsSecret(c, admin, internal)); err != nil {
+			return err
+		}
+	}
+	c.Status.CredentialsSecret = nodes.MakeCredentialsSecretName(c)
+	return nil
+}
+
+// getAdminCredentials returns the admin user and password the operator calls druid with
+func (r *ReconcileDruid) getAdminCredentials(c *binaryomenv1alpha1.Druid) (string, string, error) {
+	secret := &v1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodes.MakeCredentialsSecretName(c), Namespace: c.Namespace}, secret); err != nil {
+		return "", "", err
+	}
+	return nodes.AdminUser, string(secret.Data[nodes.AdminPasswordKey]), nil
+}
+
+func generatePassword() (string, error) {
+	b := make([]byte, passwordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
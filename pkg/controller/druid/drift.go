@@ -0,0 +1,110 @@
+package druid
+
+import (
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/drift"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// renderedAnnotation holds the hash of the rendering a resource was last written from
+const renderedAnnotation = "druid.binaryomen.org/rendered"
+
+var driftedResources = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "druid_operator_drifted_resources",
+		Help: "Resources of a Druid cluster edited outside the operator",
+	},
+	[]string{"namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(driftedResources)
+}
+
+// beginDrift starts collecting the drift of a reconcile, it returns the previous report
+func beginDrift(c *binaryomenv1alpha1.Druid) []binaryomenv1alpha1.DriftStatus {
+	previous := c.Status.Drift
+	c.Status.Drift = nil
+	return previous
+}
+
+// endDrift keeps the since timestamps of the resources still drifted from the previous report, and publishes
+// the number of drifted resources
+func endDrift(c *binaryomenv1alpha1.Druid, previous []binaryomenv1alpha1.DriftStatus) {
+	for i := range c.Status.Drift {
+		for _, d := range previous {
+			if d.Kind == c.Status.Drift[i].Kind && d.Name == c.Status.Drift[i].Name {
+				c.Status.Drift[i].Since = d.Since
+			}
+		}
+	}
+	driftedResources.WithLabelValues(c.Namespace, c.Name).Set(float64(len(c.Status.Drift)))
+}
+
+// annotateRendered marks a resource about to be created with the hash of its rendering
+func annotateRendered(desired runtime.Object) {
+	hash, err := drift.Hash(desired)
+	if err != nil {
+		return
+	}
+	if accessor, err := meta.Accessor(desired); err == nil {
+		setAnnotation(accessor, hash)
+	}
+}
+
+// detectDrift compares a rendered resource with the live one. A live resource carrying the hash of the
+// same rendering was edited outside the operator, its drift is reported and, with the Report policy, the
+// caller shall not overwrite it. Otherwise the live resource is marked with the new hash before being updated
+func (r *ReconcileDruid) detectDrift(c *binaryomenv1alpha1.Druid, desired runtime.Object, live runtime.Object) bool {
+	hash, err := drift.Hash(desired)
+	if err != nil {
+		return false
+	}
+	accessor, err := meta.Accessor(live)
+	if err != nil {
+		return false
+	}
+	if accessor.GetAnnotations()[renderedAnnotation] != hash {
+		setAnnotation(accessor, hash)
+		return false
+	}
+
+	fields, err := drift.Compare(desired, live)
+	if err != nil || len(fields) == 0 {
+		return false
+	}
+
+	kind := ""
+	if gvk, err := apiutil.GVKForObject(live, r.scheme); err == nil {
+		kind = gvk.Kind
+	}
+	for _, d := range c.Status.Drift {
+		if d.Kind == kind && d.Name == accessor.GetName() {
+			// shared resources, like the common configmap, are reconciled once per node
+			return c.Spec.DriftPolicy == binaryomenv1alpha1.DriftReport
+		}
+	}
+	c.Status.Drift = append(c.Status.Drift, binaryomenv1alpha1.DriftStatus{
+		Kind:   kind,
+		Name:   accessor.GetName(),
+		Fields: fields,
+		Since:  metav1.Now(),
+	})
+	r.log.Info("Drift detected", "Kind", kind, "Name", accessor.GetName(), "Fields", fields)
+
+	return c.Spec.DriftPolicy == binaryomenv1alpha1.DriftReport
+}
+
+func setAnnotation(accessor metav1.Object, hash string) {
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[renderedAnnotation] = hash
+	accessor.SetAnnotations(annotations)
+}
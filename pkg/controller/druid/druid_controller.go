@@ -7,11 +7,14 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 
 	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
 	"github.com/BinaryOmen/druid-operator/pkg/validation"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -28,17 +31,17 @@ const ReconcileTime = 30 * time.Second
 
 // Add creates a new Druid Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, caps *capabilities.Capabilities) error {
+	return add(mgr, newReconciler(mgr, caps), caps)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileDruid{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, caps *capabilities.Capabilities) reconcile.Reconciler {
+	return &ReconcileDruid{client: mgr.GetClient(), reader: mgr.GetAPIReader(), scheme: mgr.GetScheme(), capabilities: caps}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, caps *capabilities.Capabilities) error {
 	// Create a new controller
 	c, err := controller.New("druid-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -81,17 +84,68 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
+
+	// Watch for changes to secondary resource NetworkPolicy
+	err = c.Watch(&source.Kind{Type: &networkingv1.NetworkPolicy{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &binaryomenv1alpha1.Druid{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Ingress, on the api served by the cluster
+	if err = watchUnstructured(c, caps.IngressAPIVersion, "Ingress"); err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource HTTPRoute, when the Gateway API is installed
+	if err = watchUnstructured(c, caps.HTTPRouteAPIVersion, "HTTPRoute"); err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Route, on OpenShift
+	if err = watchUnstructured(c, caps.RouteAPIVersion, "Route"); err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resources ServiceMonitor and PodMonitor, when the prometheus-operator is installed
+	if err = watchUnstructured(c, caps.ServiceMonitorAPIVersion, "ServiceMonitor"); err != nil {
+		return err
+	}
+	if err = watchUnstructured(c, caps.PodMonitorAPIVersion, "PodMonitor"); err != nil {
+		return err
+	}
 	return nil
 }
 
+// watchUnstructured watches the resources of an optional api owned by a Druid CR, apiVersion is empty
+// when the api is not served
+func watchUnstructured(c controller.Controller, apiVersion string, kind string) error {
+	if apiVersion == "" {
+		return nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	return c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &binaryomenv1alpha1.Druid{},
+	})
+}
+
 // blank assignment to verify that ReconcileDruid implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileDruid{}
 
 // ReconcileDruid reconciles a Druid object
 type ReconcileDruid struct {
 	client client.Client
+	// reader reads from the api server, cluster scoped objects are not served by a namespaced cache
+	reader client.Reader
 	scheme *runtime.Scheme
 	log    logr.Logger
+	// capabilities lists the optional apis served by the cluster
+	capabilities *capabilities.Capabilities
 }
 
 type reconcileFun func(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error
@@ -108,29 +162,64 @@ func (r *ReconcileDruid) Reconcile(request reconcile.Request) (reconcile.Result,
 	err := r.client.Get(context.TODO(), request.NamespacedName, c)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			pausedClusters.DeleteLabelValues(request.Namespace, request.Name)
+			driftedResources.DeleteLabelValues(request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
 
+	// a paused or planned cluster is reconciled through a guarded client, its writes are reported instead of applied
+	var guard *guardedClient
+	rd := r
+	if isPaused(c) || isPlanRequested(c) {
+		r.log.Info("Druid reconciliation guarded", "paused", isPaused(c), "plan", isPlanRequested(c), "name", c.Name, "namespace", c.Namespace)
+		guard = newGuardedClient(r.client, r.scheme)
+		rd = r.withClient(guard)
+	}
+
+	if c.DeletionTimestamp != nil {
+		return rd.finalizeDruid(c)
+	}
+
 	// Validate Spec
 	validator := validation.Validator{}
 	validator.Validate(c)
 
+	if validator.WarningMessage != "" {
+		r.log.Info("Druid CR validation warnings", "warnings", validator.WarningMessage, "name", c.Name, "namespace", c.Namespace)
+	}
+
 	if !validator.Validated {
 		e := fmt.Errorf("Failed to create Druid CR due to [%s]", validator.ErrorMessage)
 		r.log.Error(e, e.Error(), "name", c.Name, "namespace", c.Namespace)
 		return reconcile.Result{}, nil
 	}
 
+	if err = rd.reconcileFinalizer(c); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// Reconcile
+	status := c.Status.DeepCopy()
 	for _, fun := range []reconcileFun{
-		r.reconileDruid,
+		rd.reconileDruid,
 	} {
 		if err = fun(cc, c); err != nil {
-			return reconcile.Result{}, err
+			break
 		}
 	}
+	setPausedStatus(c, guard)
+	setPlanStatus(c, guard)
+	rd.keepGuardedStatus(c, status)
+
+	// persist the status even on errors, it records state needed by the next reconcile
+	if uerr := rd.updateDruidStatus(c, status); uerr != nil {
+		return reconcile.Result{}, uerr
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 
 	// Recreate any missing resources every 'ReconcileTime'
 	return reconcile.Result{RequeueAfter: ReconcileTime}, nil
@@ -0,0 +1,182 @@
+package druid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileVolumeExpansion grows the claims of a statefulset to the storage requested by its volumeClaimTemplates.
+// Templates are immutable, so once every claim is resized the statefulset is recreated with the new templates
+func (r *ReconcileDruid) reconcileVolumeExpansion(c *binaryomenv1alpha1.Druid, ssCur *appsv1.StatefulSet, sts *appsv1.StatefulSet) (bool, error) {
+	expansions := []binaryomenv1alpha1.VolumeExpansionStatus{}
+	resized := true
+
+	for _, tpl := range sts.Spec.VolumeClaimTemplates {
+		requested, ok := tpl.Spec.Resources.Requests[v1.ResourceStorage]
+		if !ok {
+			continue
+		}
+		tplCur := getVolumeClaimTemplate(ssCur, tpl.Name)
+		if tplCur == nil {
+			continue
+		}
+		current := tplCur.Spec.Resources.Requests[v1.ResourceStorage]
+		if requested.Cmp(current) <= 0 {
+			continue
+		}
+
+		pvcs, err := r.listVolumeClaims(ssCur, tpl.Name)
+		if err != nil {
+			return false, err
+		}
+		for i := range pvcs {
+			status, err := r.expandVolumeClaim(&pvcs[i], requested)
+			if err != nil {
+				return false, err
+			}
+			status.StatefulSet = ssCur.Name
+			if status.Phase != binaryomenv1alpha1.VolumeExpansionCompleted {
+				resized = false
+			}
+			expansions = append(expansions, status)
+		}
+	}
+
+	setVolumeExpansionStatus(c, ssCur.Name, expansions)
+	if len(expansions) == 0 || !resized {
+		return false, nil
+	}
+
+	if err := r.recreateStatefulSet(ssCur); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recreateStatefulSet orphan deletes a statefulset whose immutable fields changed, its pods keep running
+// and are adopted by the statefulset created on the next reconcile
+func (r *ReconcileDruid) recreateStatefulSet(ssCur *appsv1.StatefulSet) error {
+	if err := r.client.Delete(context.TODO(), ssCur, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+		return err
+	}
+	r.log.Info("Orphan delete statefulSet to recreate it",
+		"StatefulSet.Namespace", ssCur.Namespace,
+		"StatefulSet.Name", ssCur.Name)
+	return nil
+}
+
+// expandVolumeClaim requests the new size for a claim and reports how far the resize went
+func (r *ReconcileDruid) expandVolumeClaim(pvc *v1.PersistentVolumeClaim, requested resource.Quantity) (binaryomenv1alpha1.VolumeExpansionStatus, error) {
+	status := binaryomenv1alpha1.VolumeExpansionStatus{
+		Claim:     pvc.Name,
+		Requested: requested.String(),
+	}
+
+	if capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+		status.Capacity = capacity.String()
+		if capacity.Cmp(requested) >= 0 {
+			status.Phase = binaryomenv1alpha1.VolumeExpansionCompleted
+			return status, nil
+		}
+	}
+
+	current := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if current.Cmp(requested) < 0 {
+		allowed, err := r.allowsVolumeExpansion(pvc)
+		if err != nil {
+			return status, err
+		}
+		if !allowed {
+			status.Phase = binaryomenv1alpha1.VolumeExpansionUnsupported
+			return status, nil
+		}
+
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		pvc.Spec.Resources.Requests[v1.ResourceStorage] = requested
+		if err = r.client.Update(context.TODO(), pvc); err != nil {
+			return status, err
+		}
+		r.log.Info("Expand persistent volume claim success",
+			"PersistentVolumeClaim.Namespace", pvc.Namespace,
+			"PersistentVolumeClaim.Name", pvc.Name,
+			"OldSize", current.String(),
+			"NewSize", requested.String())
+	}
+
+	status.Phase = binaryomenv1alpha1.VolumeExpansionResizing
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Type == v1.PersistentVolumeClaimFileSystemResizePending && cond.Status == v1.ConditionTrue {
+			status.Phase = binaryomenv1alpha1.VolumeExpansionFileSystemResizePending
+		}
+	}
+	return status, nil
+}
+
+// allowsVolumeExpansion reports whether the StorageClass of the claim can grow its volume
+func (r *ReconcileDruid) allowsVolumeExpansion(pvc *v1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := r.reader.Get(context.TODO(), types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		return false, err
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+// listVolumeClaims returns the claims the statefulset created from the named template,
+// they are labeled with the statefulset selector and named <template>-<statefulset>-<ordinal>
+func (r *ReconcileDruid) listVolumeClaims(sts *appsv1.StatefulSet, template string) ([]v1.PersistentVolumeClaim, error) {
+	pvcList := &v1.PersistentVolumeClaimList{}
+	if err := r.client.List(context.TODO(), pvcList,
+		client.InNamespace(sts.Namespace),
+		client.MatchingLabels(sts.Spec.Selector.MatchLabels),
+	); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", template, sts.Name)
+	pvcs := []v1.PersistentVolumeClaim{}
+	for _, pvc := range pvcList.Items {
+		if strings.HasPrefix(pvc.Name, prefix) {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+func getVolumeClaimTemplate(sts *appsv1.StatefulSet, name string) *v1.PersistentVolumeClaim {
+	for i := range sts.Spec.VolumeClaimTemplates {
+		if sts.Spec.VolumeClaimTemplates[i].Name == name {
+			return &sts.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	return nil
+}
+
+// setVolumeExpansionStatus replaces the expansion progress of a statefulset
+func setVolumeExpansionStatus(c *binaryomenv1alpha1.Druid, name string, expansions []binaryomenv1alpha1.VolumeExpansionStatus) {
+	status := []binaryomenv1alpha1.VolumeExpansionStatus{}
+	for _, val := range c.Status.VolumeExpansions {
+		if val.StatefulSet != name {
+			status = append(status, val)
+		}
+	}
+	status = append(status, expansions...)
+	if len(status) == 0 {
+		status = nil
+	}
+	c.Status.VolumeExpansions = status
+}
@@ -0,0 +1,148 @@
+package druid
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// druidFinalizer holds the deletion of the Druid CR until its teardown is done
+	druidFinalizer         = "binaryomen.org/druid"
+	defaultTeardownTimeout = 10 * time.Minute
+	teardownRequeueTime    = 10 * time.Second
+)
+
+// reconcileFinalizer adds the finalizer running the teardown of the cluster on deletion
+func (r *ReconcileDruid) reconcileFinalizer(c *binaryomenv1alpha1.Druid) error {
+	if hasFinalizer(c) {
+		return nil
+	}
+	c.Finalizers = append(c.Finalizers, druidFinalizer)
+	if err := r.client.Update(context.TODO(), c); err != nil {
+		return err
+	}
+	r.log.Info("Add Druid finalizer success")
+	return nil
+}
+
+// finalizeDruid tears a deleted Druid CR down, deletes the claims due on cluster deletion, then lets it go
+func (r *ReconcileDruid) finalizeDruid(c *binaryomenv1alpha1.Druid) (reconcile.Result, error) {
+	if !hasFinalizer(c) {
+		return reconcile.Result{}, nil
+	}
+
+	status := c.Status.DeepCopy()
+	done, err := r.teardownDruid(c)
+	r.keepGuardedStatus(c, status)
+	if uerr := r.updateDruidStatus(c, status); uerr != nil {
+		return reconcile.Result{}, uerr
+	}
+	if err != nil {
+		r.log.Error(err, "Tearing down Druid Error", "name", c.Name, "namespace", c.Namespace)
+		return reconcile.Result{RequeueAfter: teardownRequeueTime}, nil
+	}
+	if !done {
+		return reconcile.Result{RequeueAfter: teardownRequeueTime}, nil
+	}
+
+	for _, ns := range c.Spec.Nodes {
+		if !deleteClaimsOnClusterDelete(&ns) {
+			continue
+		}
+		sts := nodes.MakeStatefulSet(&ns, c)
+		for _, tpl := range sts.Spec.VolumeClaimTemplates {
+			pvcs, err := r.listVolumeClaims(sts, tpl.Name)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			for i := range pvcs {
+				if err = r.deleteVolumeClaim(&pvcs[i]); err != nil {
+					return reconcile.Result{}, err
+				}
+			}
+		}
+	}
+
+	c.Finalizers = removeFinalizer(c.Finalizers)
+	if err := r.client.Update(context.TODO(), c); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.log.Info("Remove Druid finalizer success")
+	if _, ok := r.client.(*guardedClient); !ok {
+		pausedClusters.DeleteLabelValues(c.Namespace, c.Name)
+		driftedResources.DeleteLabelValues(c.Namespace, c.Name)
+	}
+	return reconcile.Result{}, nil
+}
+
+// deleteScaledDownVolumeClaims deletes the claims of the ordinals removed by a scale down,
+// once the statefulset has no pod left using them
+func (r *ReconcileDruid) deleteScaledDownVolumeClaims(sts *appsv1.StatefulSet) error {
+	replicas := *sts.Spec.Replicas
+	if sts.Status.Replicas > replicas {
+		return nil
+	}
+
+	for _, tpl := range sts.Spec.VolumeClaimTemplates {
+		pvcs, err := r.listVolumeClaims(sts, tpl.Name)
+		if err != nil {
+			return err
+		}
+		for i := range pvcs {
+			ordinal, err := strconv.Atoi(pvcs[i].Name[strings.LastIndex(pvcs[i].Name, "-")+1:])
+			if err != nil || int32(ordinal) < replicas {
+				continue
+			}
+			if err = r.deleteVolumeClaim(&pvcs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileDruid) deleteVolumeClaim(pvc *v1.PersistentVolumeClaim) error {
+	if pvc.DeletionTimestamp != nil {
+		return nil
+	}
+	if err := r.client.Delete(context.TODO(), pvc); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	r.log.Info("Delete persistent volume claim success",
+		"PersistentVolumeClaim.Namespace", pvc.Namespace,
+		"PersistentVolumeClaim.Name", pvc.Name)
+	return nil
+}
+
+func deleteClaimsOnClusterDelete(cc *binaryomenv1alpha1.NodeSpec) bool {
+	return cc.PVCRetentionPolicy == binaryomenv1alpha1.PVCDeleteOnClusterDelete ||
+		cc.PVCRetentionPolicy == binaryomenv1alpha1.PVCDeleteOnScaleDown
+}
+
+func hasFinalizer(c *binaryomenv1alpha1.Druid) bool {
+	for _, f := range c.Finalizers {
+		if f == druidFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []string) []string {
+	result := []string{}
+	for _, f := range finalizers {
+		if f != druidFinalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package druid
+
+import (
+	"context"
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// guardedClient reads through the wrapped client but records writes instead of sending them,
+// status writes go through so the Druid status keeps being reported
+type guardedClient struct {
+	client.Client
+	scheme *runtime.Scheme
+	ops    []string
+	// restarts lists the statefulsets and deployments whose pods would roll, in reconcile order
+	restarts []string
+}
+
+func newGuardedClient(c client.Client, scheme *runtime.Scheme) *guardedClient {
+	return &guardedClient{Client: c, scheme: scheme}
+}
+
+func (g *guardedClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	g.record("Create", obj)
+	return nil
+}
+
+// Update is only recorded when it would change the live object, the reconcile functions update unconditionally
+func (g *guardedClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	live := obj.DeepCopyObject()
+	if err = g.Client.Get(ctx, types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}, live); err != nil {
+		return err
+	}
+	if !equality.Semantic.DeepDerivative(obj, live) {
+		g.record("Update", obj)
+	}
+
+	switch o := obj.(type) {
+	case *appsv1.StatefulSet:
+		if !equality.Semantic.DeepDerivative(o.Spec.Template, live.(*appsv1.StatefulSet).Spec.Template) {
+			g.restarts = uniqueAppend(g.restarts, fmt.Sprintf("StatefulSet %s", o.Name))
+		}
+	case *appsv1.Deployment:
+		if !equality.Semantic.DeepDerivative(o.Spec.Template, live.(*appsv1.Deployment).Spec.Template) {
+			g.restarts = uniqueAppend(g.restarts, fmt.Sprintf("Deployment %s", o.Name))
+		}
+	}
+	return nil
+}
+
+func (g *guardedClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	g.record("Delete", obj)
+	return nil
+}
+
+func (g *guardedClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	g.record("Patch", obj)
+	return nil
+}
+
+func (g *guardedClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	g.record("DeleteAllOf", obj)
+	return nil
+}
+
+// record keeps a write as "<op> <kind> <name>", once
+func (g *guardedClient) record(op string, obj runtime.Object) {
+	kind := ""
+	if gvk, err := apiutil.GVKForObject(obj, g.scheme); err == nil {
+		kind = gvk.Kind
+	}
+	name := ""
+	if accessor, err := meta.Accessor(obj); err == nil {
+		name = accessor.GetName()
+	}
+	g.ops = uniqueAppend(g.ops, fmt.Sprintf("%s %s %s", op, kind, name))
+}
+
+// recordAction keeps a call to the druid api which was not sent
+func (g *guardedClient) recordAction(action string) {
+	g.ops = uniqueAppend(g.ops, action)
+}
+
+// keepGuardedStatus drops the state computed by a guarded reconcile, which was not applied,
+// only the paused, plan and drift reports are kept
+func (r *ReconcileDruid) keepGuardedStatus(c *binaryomenv1alpha1.Druid, previous *binaryomenv1alpha1.DruidStatus) {
+	if _, ok := r.client.(*guardedClient); !ok {
+		return
+	}
+	status := previous.DeepCopy()
+	status.Paused = c.Status.Paused
+	status.Plan = c.Status.Plan
+	status.Drift = c.Status.Drift
+	c.Status = *status
+}
@@ -0,0 +1,188 @@
+package druid
+
+import (
+	"context"
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileHibernation shall record the replicas and suspend the supervisors of a suspended cluster,
+// the nodes are then scaled by reconcileDruidNodes. Suspending is retried while hibernating, so that
+// failed calls and supervisors submitted meanwhile are suspended before the nodes scale down
+func (r *ReconcileDruid) reconcileHibernation(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	h := c.Status.Hibernation
+
+	if !c.Spec.Suspended {
+		if h != nil && h.Phase != binaryomenv1alpha1.HibernationResuming {
+			h.Phase = binaryomenv1alpha1.HibernationResuming
+			r.log.Info("Resuming Druid cluster", "name", c.Name)
+		}
+		return nil
+	}
+
+	if h != nil && h.Phase == binaryomenv1alpha1.HibernationHibernated {
+		return nil
+	}
+	if h == nil {
+		h = &binaryomenv1alpha1.HibernationStatus{Replicas: map[string]int32{}, SpecReplicas: map[string]int32{}}
+		for _, ns := range c.Spec.Nodes {
+			h.Replicas[ns.Name] = r.getNodeReplicas(&ns, c)
+			h.SpecReplicas[ns.Name] = ns.Replicas
+		}
+		c.Status.Hibernation = h
+	}
+	if h.Phase != binaryomenv1alpha1.HibernationHibernating {
+		h.Phase = binaryomenv1alpha1.HibernationHibernating
+		r.log.Info("Hibernating Druid cluster", "name", c.Name)
+	}
+
+	client, ok, err := r.newDruidClient(c)
+	if err != nil {
+		return err
+	}
+	if ok {
+		suspended, err := r.suspendSupervisors(client)
+		h.SuspendedSupervisors = uniqueAppend(h.SuspendedSupervisors, suspended...)
+		if err != nil {
+			return err
+		}
+	}
+	if _, guarded := r.client.(*guardedClient); !guarded {
+		h.SupervisorsSuspended = true
+	}
+	return nil
+}
+
+// completeHibernation shall move a hibernating cluster to hibernated once its pods are gone, and resume
+// the supervisors of a resuming cluster once every node is ready
+func (r *ReconcileDruid) completeHibernation(c *binaryomenv1alpha1.Druid, scaled bool) error {
+	h := c.Status.Hibernation
+	if !scaled {
+		return nil
+	}
+
+	switch h.Phase {
+	case binaryomenv1alpha1.HibernationHibernating:
+		if !h.SupervisorsSuspended {
+			return nil
+		}
+		h.Phase = binaryomenv1alpha1.HibernationHibernated
+		r.log.Info("Druid cluster hibernated", "name", c.Name)
+	case binaryomenv1alpha1.HibernationResuming:
+		if len(h.SuspendedSupervisors) > 0 {
+			client, ok, err := r.newDruidClient(c)
+			if err != nil || !ok {
+				return err
+			}
+			if guard, ok := r.client.(*guardedClient); ok {
+				for _, id := range h.SuspendedSupervisors {
+					guard.recordAction(fmt.Sprintf("Resume Supervisor %s", id))
+				}
+				return nil
+			}
+			for len(h.SuspendedSupervisors) > 0 {
+				if err := client.ResumeSupervisor(h.SuspendedSupervisors[0]); err != nil {
+					return err
+				}
+				r.log.Info("Resume supervisor success", "Supervisor", h.SuspendedSupervisors[0])
+				h.SuspendedSupervisors = h.SuspendedSupervisors[1:]
+			}
+		}
+		c.Status.Hibernation = nil
+		r.log.Info("Druid cluster resumed", "name", c.Name)
+	}
+	return nil
+}
+
+// getHibernationReplicas returns zero while hibernating once every supervisor is suspended, and the recorded
+// replicas while resuming once the nodes ahead in the prescribed order are scaled
+func getHibernationReplicas(c *binaryomenv1alpha1.Druid, cc *binaryomenv1alpha1.NodeSpec, previousScaled bool) int32 {
+	h := c.Status.Hibernation
+	if h.Phase == binaryomenv1alpha1.HibernationHibernating && !h.SupervisorsSuspended {
+		return getRecordedReplicas(h, cc)
+	}
+	if h.Phase != binaryomenv1alpha1.HibernationResuming || !previousScaled {
+		return 0
+	}
+	return getRecordedReplicas(h, cc)
+}
+
+// getRecordedReplicas returns the replicas recorded when hibernating, unless the spec replicas changed since
+func getRecordedReplicas(h *binaryomenv1alpha1.HibernationStatus, cc *binaryomenv1alpha1.NodeSpec) int32 {
+	if replicas, ok := h.SpecReplicas[cc.Name]; ok && replicas != cc.Replicas {
+		return cc.Replicas
+	}
+	if replicas, ok := h.Replicas[cc.Name]; ok {
+		return replicas
+	}
+	return cc.Replicas
+}
+
+// getNodeReplicas returns the replicas of the statefulset or deployment of a node, or those of the spec
+// when it does not exist yet
+func (r *ReconcileDruid) getNodeReplicas(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) int32 {
+	name := types.NamespacedName{Name: nodes.MakeStatefulSet(cc, c).Name, Namespace: c.Namespace}
+	if cc.NodeType == historical || cc.NodeType == middleManager {
+		sts := &appsv1.StatefulSet{}
+		if err := r.client.Get(context.TODO(), name, sts); err == nil && sts.Spec.Replicas != nil {
+			return *sts.Spec.Replicas
+		}
+		return cc.Replicas
+	}
+	d := &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), name, d); err == nil && d.Spec.Replicas != nil {
+		return *d.Spec.Replicas
+	}
+	return cc.Replicas
+}
+
+// isNodeScaled reports whether the statefulset or deployment of a node runs exactly its replicas, all ready.
+// Node types the operator runs no workload for are scaled, a workload not created yet is scaled to zero only
+func (r *ReconcileDruid) isNodeScaled(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (bool, error) {
+	name := types.NamespacedName{Name: nodes.MakeStatefulSet(cc, c).Name, Namespace: c.Namespace}
+	var replicas, readyReplicas int32
+	switch cc.NodeType {
+	case historical, middleManager:
+		sts := &appsv1.StatefulSet{}
+		if err := r.client.Get(context.TODO(), name, sts); err != nil {
+			return isNotFoundScaled(cc, err)
+		}
+		replicas, readyReplicas = sts.Status.Replicas, sts.Status.ReadyReplicas
+	case overlord, router, broker, coordinator:
+		d := &appsv1.Deployment{}
+		if err := r.client.Get(context.TODO(), name, d); err != nil {
+			return isNotFoundScaled(cc, err)
+		}
+		replicas, readyReplicas = d.Status.Replicas, d.Status.ReadyReplicas
+	default:
+		return true, nil
+	}
+	return replicas == cc.Replicas && readyReplicas == cc.Replicas, nil
+}
+
+func isNotFoundScaled(cc *binaryomenv1alpha1.NodeSpec, err error) (bool, error) {
+	if errors.IsNotFound(err) {
+		return cc.Replicas == 0, nil
+	}
+	return false, err
+}
+
+func uniqueAppend(values []string, more ...string) []string {
+	for _, m := range more {
+		found := false
+		for _, val := range values {
+			if val == m {
+				found = true
+			}
+		}
+		if !found {
+			values = append(values, m)
+		}
+	}
+	return values
+}
@@ -0,0 +1,101 @@
+package druid
+
+import (
+	"context"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	"github.com/BinaryOmen/druid-operator/pkg/sync"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// reconcileMonitoring shall reconcile the ServiceMonitor or PodMonitor of the cluster, it is skipped when the
+// prometheus-operator CRDs are not installed, the nodes still serve their metrics
+func (r *ReconcileDruid) reconcileMonitoring(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (err error) {
+	if !nodes.IsMonitoringEnabled(c) {
+		return nil
+	}
+	kind := nodes.GetMonitorKind(c)
+	apiVersion := r.capabilities.MonitorAPIVersion(kind)
+	if apiVersion == "" {
+		r.log.Info("Skipping monitor, the prometheus-operator CRDs are not installed", "Kind", kind)
+		return nil
+	}
+
+	monitorCreate := nodes.MakeMonitor(c, apiVersion)
+	monitorCur := &unstructured.Unstructured{}
+	monitorCur.SetGroupVersionKind(monitorCreate.GroupVersionKind())
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      monitorCreate.GetName(),
+		Namespace: monitorCreate.GetNamespace(),
+	}, monitorCur)
+	if meta.IsNoMatchError(err) {
+		r.log.Info("Skipping monitor, the prometheus-operator CRDs are not installed", "Kind", kind)
+		return nil
+	}
+	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(monitorCreate)
+		if err = controllerutil.SetControllerReference(c, monitorCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), monitorCreate); err == nil {
+			r.log.Info("Create monitor success",
+				"Kind", kind,
+				"Monitor.Namespace", c.Namespace,
+				"Monitor.Name", monitorCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if r.detectDrift(c, monitorCreate, monitorCur) {
+			return nil
+		}
+		sync.SyncMonitor(monitorCur, monitorCreate)
+		if err = r.client.Update(context.TODO(), monitorCur); err == nil {
+			r.log.Info("Update monitor success", "Kind", kind)
+		}
+	}
+	return
+}
+
+// deleteStaleMonitors shall delete the owned ServiceMonitor or PodMonitor no longer rendered, once monitoring is
+// disabled or its kind changed, so that the nodes are not scraped twice
+func (r *ReconcileDruid) deleteStaleMonitors(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	for _, kind := range []string{binaryomenv1alpha1.MonitorService, binaryomenv1alpha1.MonitorPod} {
+		if nodes.IsMonitoringEnabled(c) && nodes.GetMonitorKind(c) == kind {
+			continue
+		}
+		apiVersion := r.capabilities.MonitorAPIVersion(kind)
+		if apiVersion == "" {
+			continue
+		}
+
+		monitor := &unstructured.Unstructured{}
+		monitor.SetAPIVersion(apiVersion)
+		monitor.SetKind(kind)
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: c.Name, Namespace: c.Namespace}, monitor)
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(monitor, c) {
+			continue
+		}
+		if err = r.client.Delete(context.TODO(), monitor); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		r.log.Info("Delete monitor success",
+			"Kind", kind,
+			"Monitor.Namespace", c.Namespace,
+			"Monitor.Name", c.Name)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package druid
+
+import (
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// pausedAnnotation set to "true" stops the operator from writing any resource of the cluster
+	pausedAnnotation = "druid.binaryomen.org/paused"
+	// pausedByAnnotation optionally tells who paused the cluster and why
+	pausedByAnnotation = "druid.binaryomen.org/paused-by"
+)
+
+var pausedClusters = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "druid_operator_paused_clusters",
+		Help: "Druid clusters whose reconciliation is paused, 1 while paused",
+	},
+	[]string{"namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(pausedClusters)
+}
+
+func isPaused(c *binaryomenv1alpha1.Druid) bool {
+	return c.Annotations[pausedAnnotation] == "true"
+}
+
+// withClient returns a copy of the reconciler using another client
+func (r *ReconcileDruid) withClient(c client.Client) *ReconcileDruid {
+	rd := *r
+	rd.client = c
+	return &rd
+}
+
+// setPausedStatus reports who paused the cluster, since when, and the writes skipped meanwhile
+func setPausedStatus(c *binaryomenv1alpha1.Druid, guard *guardedClient) {
+	if guard == nil || !isPaused(c) {
+		c.Status.Paused = nil
+		pausedClusters.DeleteLabelValues(c.Namespace, c.Name)
+		return
+	}
+
+	if c.Status.Paused == nil {
+		c.Status.Paused = &binaryomenv1alpha1.PausedStatus{Since: metav1.Now()}
+	}
+	c.Status.Paused.By = c.Annotations[pausedByAnnotation]
+	c.Status.Paused.Drift = guard.ops
+	pausedClusters.WithLabelValues(c.Namespace, c.Name).Set(1)
+}
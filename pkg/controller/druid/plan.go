@@ -0,0 +1,40 @@
+package druid
+
+import (
+	"reflect"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// planAnnotation requests a plan of the changes instead of applying them, the plan is recomputed on every
+// reconcile while the annotation is set and the changes are applied once it is removed
+const planAnnotation = "druid.binaryomen.org/plan"
+
+func isPlanRequested(c *binaryomenv1alpha1.Druid) bool {
+	_, ok := c.Annotations[planAnnotation]
+	return ok
+}
+
+// setPlanStatus publishes the writes, supervisor calls and rolling restarts recorded by the guarded client,
+// in the order the reconcile would perform them
+func setPlanStatus(c *binaryomenv1alpha1.Druid, guard *guardedClient) {
+	if guard == nil || !isPlanRequested(c) {
+		c.Status.Plan = nil
+		return
+	}
+
+	plan := &binaryomenv1alpha1.PlanStatus{
+		Request:    c.Annotations[planAnnotation],
+		Operations: guard.ops,
+		Restarts:   guard.restarts,
+	}
+	if prev := c.Status.Plan; prev != nil && prev.Request == plan.Request &&
+		reflect.DeepEqual(prev.Operations, plan.Operations) && reflect.DeepEqual(prev.Restarts, plan.Restarts) {
+		// keep the timestamp so an unchanged plan does not update the status every reconcile
+		plan.ComputedAt = prev.ComputedAt
+	} else {
+		plan.ComputedAt = metav1.Now()
+	}
+	c.Status.Plan = plan
+}
@@ -3,17 +3,25 @@ package druid
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
 	"github.com/BinaryOmen/druid-operator/pkg/sync"
-	extensions "k8s.io/api/extensions/v1beta1"
 
 	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
@@ -33,9 +41,19 @@ type keyAndNodeSpec struct {
 }
 
 func (r *ReconcileDruid) reconileDruid(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	previousDrift := beginDrift(c)
+	defer endDrift(c, previousDrift)
 
 	for _, fun := range []reconcileFun{
+		r.reconcileZookeeper,
+		r.reconcileDiscovery,
+		r.reconcileTLS,
+		r.reconcileAuthentication,
+		r.reconcileHibernation,
 		r.reconcileDruidNodes,
+		r.deleteStaleNetworkPolicies,
+		r.reconcileMonitoring,
+		r.deleteStaleMonitors,
 	} {
 		if err := fun(cc, c); err != nil {
 			r.log.Error(err, "Reconciling DruidCluster  Error", cc)
@@ -49,74 +67,189 @@ func (r *ReconcileDruid) reconileDruid(cc *binaryomenv1alpha1.NodeSpec, c *binar
 // TODO: Add running status
 func (r *ReconcileDruid) reconcileDruidNodes(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (err error) {
 	allNodeSpecs, _ := getAllNodeSpecsInDruidPrescribedOrder(c)
+	c.Status.Tiers = getTierStatus(allNodeSpecs)
 
+	scaled := true
 	for _, elem := range allNodeSpecs {
 
 		ns := elem.spec
+		if c.Status.Hibernation != nil {
+			ns.Replicas = getHibernationReplicas(c, &ns, scaled)
+		}
 
-		// create common properties configmap
-		driuidCmRuntime := nodes.MakeConfigMapNode(&ns, c)
-		err = r.reconcileConfigMap(&ns, c, driuidCmRuntime)
+		var o *nodeObjects
+		if o, err = makeNodeObjects(&ns, c, r.capabilities); err != nil {
+			r.log.Error(err, "Making PDB Error", cc)
+		}
+		if tpl := o.podTemplate(); tpl != nil {
+			if err = r.setTLSChecksum(&ns, c, tpl); err != nil {
+				r.log.Error(err, "Reading Node Certificate Error", cc)
+			}
+		}
+
+		// create node runtime properties configmap
+		err = r.reconcileConfigMap(&ns, c, o.configMap)
 		if err != nil {
 			r.log.Error(err, "Reconciling CM Runtime Properties Error", cc)
 		}
-		// create node runtime properties configmap
-		druidCmCommon := nodes.MakeConfigMapCommon(&ns, c)
-		err = r.reconcileConfigMap(&ns, c, druidCmCommon)
+		// create common properties configmap
+		err = r.reconcileConfigMap(&ns, c, o.commonConfigMap)
 		if err != nil {
 			r.log.Error(err, "Reconciling CM Common Properties Error", cc)
 		}
-		// create statefulsets for historicals and middlemanagers
-		if ns.NodeType == historical || ns.NodeType == middleManager {
-			sts := nodes.MakeStatefulSet(&ns, c)
-			err = r.reconcileSts(&ns, c, sts)
+		// create statefulsets for historicals and middlemanagers, behind their governing headless service
+		if o.statefulSet != nil {
+			err = r.reconcileService(&ns, c, o.headlessService)
+			if err != nil {
+				r.log.Error(err, "Reconciling Headless Service Error", cc)
+			}
+			err = r.reconcileSts(&ns, c, o.statefulSet)
 			if err != nil {
 				r.log.Error(err, "Reconciling Statefull Nodes Error", cc)
 			}
 
 		}
 		// create deployments for overlord, router, broker and coordinator
-		if ns.NodeType == overlord || ns.NodeType == router || ns.NodeType == broker || ns.NodeType == coordinator {
-			d := nodes.MakeDeployment(&ns, c)
-			err = r.reconcileDeployment(&ns, c, d)
+		if o.deployment != nil {
+			err = r.reconcileDeployment(&ns, c, o.deployment)
 			if err != nil {
 				r.log.Error(err, "Reconciling Stateless Nodes Error", cc)
 			}
 		}
 		// create druid service
-		druidSvc := nodes.MakeService(&ns, c)
-		err = r.reconcileService(&ns, c, druidSvc)
+		err = r.reconcileService(&ns, c, o.service)
 		if err != nil {
 			r.log.Error(err, "Reconciling  Druid Service Error", cc)
 		}
 
 		// create ingress
 		if ns.Ingress.Enabled == true {
-			ing := nodes.MakeDruidIngress(&ns, c)
-			err = r.reconcileIngress(&ns, c, ing)
+			if o.ingress == nil {
+				r.log.Info("Skipping Ingress, no Ingress api is served", "Node", ns.Name)
+			} else {
+				err = r.reconcileIngress(&ns, c, o.ingress)
+				if err != nil {
+					r.log.Error(err, "Reconcile Ingress Error", "Ingress", o.ingress.GetName())
+				}
+			}
+		}
+		// create httproute
+		if ns.Gateway != nil {
+			if o.httpRoute == nil {
+				r.log.Info("Skipping HTTPRoute, the Gateway API is not installed", "Node", ns.Name)
+			} else {
+				err = r.reconcileHTTPRoute(&ns, c, o.httpRoute)
+				if err != nil {
+					r.log.Error(err, "Reconcile HTTPRoute Error", "HTTPRoute", o.httpRoute.GetName())
+				}
+			}
+		}
+		// create openshift route
+		if ns.Route != nil {
+			if o.route == nil {
+				r.log.Info("Skipping Route, the Route api is only served by OpenShift", "Node", ns.Name)
+			} else {
+				err = r.reconcileRoute(&ns, c, o.route)
+				if err != nil {
+					r.log.Error(err, "Reconcile Route Error", "Route", o.route.GetName())
+				}
+			}
+		}
+		// create networkpolicy
+		if o.networkPolicy != nil {
+			err = r.reconcileNetworkPolicy(c, o.networkPolicy)
 			if err != nil {
-				r.log.Error(err, "Reconcile Ingress Error", ing)
+				r.log.Error(err, "Reconciling NetworkPolicy Error", cc)
 			}
-
 		}
 		// create poddisruptionbudget
-		if ns.PodDisruptionBudget == true {
-			pdb, err := nodes.MakePodDisruptionBudget(&ns, c)
+		if o.pdb != nil {
+			err = r.reconcilePdb(&ns, c, o.pdb)
 			if err != nil {
-				r.log.Error(err, "Making PDB Error", cc)
+				r.log.Error(err, "Reconciling Druid PDB Error", cc)
 			}
-			err = r.reconcilePdb(&ns, c, pdb)
+		}
+
+		if c.Status.Hibernation != nil {
+			nodeScaled, err := r.isNodeScaled(&ns, c)
 			if err != nil {
-				r.log.Error(err, "Reconciling Druid PDB Error", cc)
+				r.log.Error(err, "Reading Node Replicas Error", cc)
 			}
+			scaled = scaled && nodeScaled
 		}
+	}
 
+	if c.Status.Hibernation != nil {
+		err = r.completeHibernation(c, scaled)
 	}
 
 	return
 }
 
-// reconcileSts will reconcile statefulsets
+// reconcileZookeeper shall create the managed zookeeper ensemble ahead of the druid nodes
+func (r *ReconcileDruid) reconcileZookeeper(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (err error) {
+	if !nodes.IsZookeeperManaged(c) {
+		c.Status.Zookeeper = nil
+		return nil
+	}
+
+	sts := nodes.MakeZookeeperStatefulSet(c)
+	if err = r.reconcileSts(nil, c, sts); err != nil {
+		r.log.Error(err, "Reconciling Zookeeper StatefulSet Error", "name", sts.Name)
+		return err
+	}
+	if err = r.reconcileService(nil, c, nodes.MakeZookeeperService(c)); err != nil {
+		r.log.Error(err, "Reconciling Zookeeper Service Error", "name", sts.Name)
+		return err
+	}
+	if err = r.reconcilePdb(nil, c, nodes.MakeZookeeperPodDisruptionBudget(c)); err != nil {
+		r.log.Error(err, "Reconciling Zookeeper PDB Error", "name", sts.Name)
+		return err
+	}
+	if nodes.IsNetworkPolicyEnabled(c) {
+		if err = r.reconcileNetworkPolicy(c, nodes.MakeZookeeperNetworkPolicy(c)); err != nil {
+			r.log.Error(err, "Reconciling Zookeeper NetworkPolicy Error", "name", sts.Name)
+			return err
+		}
+	}
+
+	ssCur := &appsv1.StatefulSet{}
+	if err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      sts.Name,
+		Namespace: sts.Namespace,
+	}, ssCur); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.Status.Zookeeper = &binaryomenv1alpha1.ZookeeperStatus{
+		ConnectString: nodes.GetZookeeperConnectString(c),
+		Replicas:      ssCur.Status.Replicas,
+		ReadyReplicas: ssCur.Status.ReadyReplicas,
+	}
+	return nil
+}
+
+// reconcileDiscovery shall create the service account, role and rolebinding used by the kubernetes discovery
+func (r *ReconcileDruid) reconcileDiscovery(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (err error) {
+	if c.Spec.Discovery != binaryomenv1alpha1.DiscoveryKubernetes {
+		return nil
+	}
+
+	if err = r.reconcileServiceAccount(c, nodes.MakeServiceAccount(c)); err != nil {
+		r.log.Error(err, "Reconciling Service Account Error", "name", c.Name)
+		return err
+	}
+	if err = r.reconcileRole(c, nodes.MakeRole(c)); err != nil {
+		r.log.Error(err, "Reconciling Role Error", "name", c.Name)
+		return err
+	}
+	if err = r.reconcileRoleBinding(c, nodes.MakeRoleBinding(c)); err != nil {
+		r.log.Error(err, "Reconciling RoleBinding Error", "name", c.Name)
+		return err
+	}
+	return nil
+}
+
+// reconcileSts will reconcile statefulsets, cc is nil for statefulsets which are not druid nodes
 func (r *ReconcileDruid) reconcileSts(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, sts *appsv1.StatefulSet) (err error) {
 	ssCur := &appsv1.StatefulSet{}
 	err = r.client.Get(context.TODO(), types.NamespacedName{
@@ -124,6 +257,7 @@ func (r *ReconcileDruid) reconcileSts(cc *binaryomenv1alpha1.NodeSpec, c *binary
 		Namespace: sts.Namespace,
 	}, ssCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(sts)
 		if err = controllerutil.SetControllerReference(c, sts, r.scheme); err != nil {
 			return err
 		}
@@ -136,16 +270,36 @@ func (r *ReconcileDruid) reconcileSts(cc *binaryomenv1alpha1.NodeSpec, c *binary
 	} else if err != nil {
 		return err
 	} else {
-		if cc.Replicas != *ssCur.Spec.Replicas {
+		if ssCur.DeletionTimestamp != nil {
+			// orphan deleted by a volume expansion, recreated once gone
+			return nil
+		}
+		if ssCur.Spec.ServiceName != sts.Spec.ServiceName {
+			// serviceName is immutable
+			return r.recreateStatefulSet(ssCur)
+		}
+		recreate, err := r.reconcileVolumeExpansion(c, ssCur, sts)
+		if err != nil || recreate {
+			return err
+		}
+		if r.detectDrift(c, sts, ssCur) {
+			return nil
+		}
+		if *sts.Spec.Replicas != *ssCur.Spec.Replicas {
 			old := *ssCur.Spec.Replicas
-			ssCur.Spec.Replicas = &cc.Replicas
+			ssCur.Spec.Replicas = sts.Spec.Replicas
 			if err = r.client.Update(context.TODO(), ssCur); err == nil {
 				r.log.Info("Scale  statefulSet success.",
 					"OldSize", old,
-					"NewSize", cc.Replicas)
+					"NewSize", *sts.Spec.Replicas)
 			}
 
 		}
+		if cc != nil && cc.PVCRetentionPolicy == binaryomenv1alpha1.PVCDeleteOnScaleDown && c.Status.Hibernation == nil {
+			if err = r.deleteScaledDownVolumeClaims(ssCur); err != nil {
+				return err
+			}
+		}
 		return r.updateStatefulSet(ssCur, sts)
 	}
 
@@ -165,6 +319,7 @@ func (r *ReconcileDruid) reconcileDeployment(cc *binaryomenv1alpha1.NodeSpec, c
 		Namespace: dmCreate.Namespace,
 	}, dmCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(dmCreate)
 		if err = controllerutil.SetControllerReference(c, dmCreate, r.scheme); err != nil {
 			return err
 		}
@@ -177,6 +332,9 @@ func (r *ReconcileDruid) reconcileDeployment(cc *binaryomenv1alpha1.NodeSpec, c
 	} else if err != nil {
 		return err
 	} else {
+		if r.detectDrift(c, dmCreate, dmCur) {
+			return nil
+		}
 		if cc.Replicas != *dmCur.Spec.Replicas {
 			old := *dmCur.Spec.Replicas
 			dmCur.Spec.Replicas = &cc.Replicas
@@ -199,6 +357,7 @@ func (r *ReconcileDruid) reconcileConfigMap(cc *binaryomenv1alpha1.NodeSpec, c *
 		Namespace: cmCreate.Namespace,
 	}, cmCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(cmCreate)
 		if err = controllerutil.SetControllerReference(c, cmCreate, r.scheme); err != nil {
 			return err
 		}
@@ -211,6 +370,9 @@ func (r *ReconcileDruid) reconcileConfigMap(cc *binaryomenv1alpha1.NodeSpec, c *
 	} else if err != nil {
 		return err
 	} else {
+		if r.detectDrift(c, cmCreate, cmCur) {
+			return nil
+		}
 		if err = r.client.Update(context.TODO(), cmCur); err == nil {
 			r.log.Info("Update configmap success")
 		}
@@ -227,6 +389,7 @@ func (r *ReconcileDruid) reconcileService(cc *binaryomenv1alpha1.NodeSpec, c *bi
 		Namespace: svcCreate.Namespace,
 	}, svcCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(svcCreate)
 		if err = controllerutil.SetControllerReference(c, svcCreate, r.scheme); err != nil {
 			return err
 		}
@@ -239,8 +402,8 @@ func (r *ReconcileDruid) reconcileService(cc *binaryomenv1alpha1.NodeSpec, c *bi
 	} else if err != nil {
 		return err
 	} else {
-		if err = r.client.Update(context.TODO(), svcCur); err == nil {
-			r.log.Info("Update Service success")
+		if r.detectDrift(c, svcCreate, svcCur) {
+			return nil
 		}
 		return r.updateService(c, svcCur, svcCreate)
 	}
@@ -255,6 +418,7 @@ func (r *ReconcileDruid) reconcilePdb(cc *binaryomenv1alpha1.NodeSpec, c *binary
 		Namespace: pdbCreate.Namespace,
 	}, pdbCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(pdbCreate)
 		if err = controllerutil.SetControllerReference(c, pdbCreate, r.scheme); err != nil {
 			return err
 		}
@@ -267,6 +431,9 @@ func (r *ReconcileDruid) reconcilePdb(cc *binaryomenv1alpha1.NodeSpec, c *binary
 	} else if err != nil {
 		return err
 	} else {
+		if r.detectDrift(c, pdbCreate, pdbCur) {
+			return nil
+		}
 		if err = r.client.Update(context.TODO(), pdbCur); err == nil {
 			r.log.Info("Update Service success")
 		}
@@ -274,14 +441,157 @@ func (r *ReconcileDruid) reconcilePdb(cc *binaryomenv1alpha1.NodeSpec, c *binary
 	return
 }
 
-// reconcileIngress shall reconcile ingress spec
-func (r *ReconcileDruid) reconcileIngress(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, ingCreate *extensions.Ingress) (err error) {
-	ingCur := &extensions.Ingress{}
+// reconcileNetworkPolicy shall reconcile the networkpolicy of a node
+func (r *ReconcileDruid) reconcileNetworkPolicy(c *binaryomenv1alpha1.Druid, npCreate *networkingv1.NetworkPolicy) (err error) {
+	npCur := &networkingv1.NetworkPolicy{}
 	err = r.client.Get(context.TODO(), types.NamespacedName{
-		Name:      ingCreate.Name,
-		Namespace: ingCreate.Namespace,
+		Name:      npCreate.Name,
+		Namespace: npCreate.Namespace,
+	}, npCur)
+	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(npCreate)
+		if err = controllerutil.SetControllerReference(c, npCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), npCreate); err == nil {
+			r.log.Info("Create NetworkPolicy success",
+				"NetworkPolicy.Namespace", c.Namespace,
+				"NetworkPolicy.Name", npCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if r.detectDrift(c, npCreate, npCur) {
+			return nil
+		}
+		sync.SyncNetworkPolicy(npCur, npCreate)
+		if err = r.client.Update(context.TODO(), npCur); err == nil {
+			r.log.Info("Update NetworkPolicy success")
+		}
+	}
+	return
+}
+
+// deleteStaleNetworkPolicies shall delete the networkpolicies owned by the cluster which are not rendered anymore,
+// all of them once NetworkPolicy is disabled, so that a removed node or feature does not keep denying traffic
+func (r *ReconcileDruid) deleteStaleNetworkPolicies(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	wanted := map[string]bool{}
+	if nodes.IsNetworkPolicyEnabled(c) {
+		for _, ns := range c.Spec.Nodes {
+			wanted[nodes.MakeNetworkPolicy(&ns, c).Name] = true
+		}
+		if nodes.IsZookeeperManaged(c) {
+			wanted[nodes.MakeZookeeperNetworkPolicy(c).Name] = true
+		}
+	}
+
+	npList := &networkingv1.NetworkPolicyList{}
+	if err := r.client.List(context.TODO(), npList, client.InNamespace(c.Namespace)); err != nil {
+		return err
+	}
+	for i := range npList.Items {
+		np := &npList.Items[i]
+		if wanted[np.Name] || !strings.HasSuffix(np.Name, "-ingress") || !metav1.IsControlledBy(np, c) {
+			continue
+		}
+		if err := r.client.Delete(context.TODO(), np); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		r.log.Info("Delete NetworkPolicy success",
+			"NetworkPolicy.Namespace", np.Namespace,
+			"NetworkPolicy.Name", np.Name)
+	}
+	return nil
+}
+
+// reconcileServiceAccount shall reconcile the druid service account
+func (r *ReconcileDruid) reconcileServiceAccount(c *binaryomenv1alpha1.Druid, saCreate *v1.ServiceAccount) (err error) {
+	saCur := &v1.ServiceAccount{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      saCreate.Name,
+		Namespace: saCreate.Namespace,
+	}, saCur)
+	if err != nil && errors.IsNotFound(err) {
+		if err = controllerutil.SetControllerReference(c, saCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), saCreate); err == nil {
+			r.log.Info("Create  Service Account success",
+				"ServiceAccount.Namespace", c.Namespace,
+				"ServiceAccount.Name", saCreate.GetName())
+		}
+	}
+	return
+}
+
+// reconcileRole shall reconcile the druid role
+func (r *ReconcileDruid) reconcileRole(c *binaryomenv1alpha1.Druid, roleCreate *rbacv1.Role) (err error) {
+	roleCur := &rbacv1.Role{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      roleCreate.Name,
+		Namespace: roleCreate.Namespace,
+	}, roleCur)
+	if err != nil && errors.IsNotFound(err) {
+		if err = controllerutil.SetControllerReference(c, roleCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), roleCreate); err == nil {
+			r.log.Info("Create  Role success",
+				"Role.Namespace", c.Namespace,
+				"Role.Name", roleCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		sync.SyncRole(roleCur, roleCreate)
+		if err = r.client.Update(context.TODO(), roleCur); err == nil {
+			r.log.Info("Update Role success")
+		}
+	}
+	return
+}
+
+// reconcileRoleBinding shall reconcile the druid rolebinding
+func (r *ReconcileDruid) reconcileRoleBinding(c *binaryomenv1alpha1.Druid, rbCreate *rbacv1.RoleBinding) (err error) {
+	rbCur := &rbacv1.RoleBinding{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      rbCreate.Name,
+		Namespace: rbCreate.Namespace,
+	}, rbCur)
+	if err != nil && errors.IsNotFound(err) {
+		if err = controllerutil.SetControllerReference(c, rbCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), rbCreate); err == nil {
+			r.log.Info("Create  RoleBinding success",
+				"RoleBinding.Namespace", c.Namespace,
+				"RoleBinding.Name", rbCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		sync.SyncRoleBinding(rbCur, rbCreate)
+		if err = r.client.Update(context.TODO(), rbCur); err == nil {
+			r.log.Info("Update RoleBinding success")
+		}
+	}
+	return
+}
+
+// reconcileIngress shall reconcile ingress spec, on the Ingress api served by the cluster
+func (r *ReconcileDruid) reconcileIngress(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, ingCreate *unstructured.Unstructured) (err error) {
+	ingCur := &unstructured.Unstructured{}
+	ingCur.SetGroupVersionKind(ingCreate.GroupVersionKind())
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      ingCreate.GetName(),
+		Namespace: ingCreate.GetNamespace(),
 	}, ingCur)
 	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(ingCreate)
 		if err = controllerutil.SetControllerReference(c, ingCreate, r.scheme); err != nil {
 			return err
 		}
@@ -294,14 +604,103 @@ func (r *ReconcileDruid) reconcileIngress(cc *binaryomenv1alpha1.NodeSpec, c *bi
 	} else if err != nil {
 		return err
 	} else {
-		if err = r.client.Update(context.TODO(), ingCur); err == nil {
-			r.log.Info("Update Ingress success")
+		if r.detectDrift(c, ingCreate, ingCur) {
+			return nil
 		}
+		// the annotations are synced, they shall keep the rendered hash
+		annotateRendered(ingCreate)
 		return r.updateIng(c, ingCur, ingCreate)
 	}
 	return
 }
 
+// reconcileHTTPRoute shall reconcile the httproute, skipped when the Gateway API CRDs were removed
+func (r *ReconcileDruid) reconcileHTTPRoute(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, routeCreate *unstructured.Unstructured) (err error) {
+	routeCur := &unstructured.Unstructured{}
+	routeCur.SetGroupVersionKind(routeCreate.GroupVersionKind())
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      routeCreate.GetName(),
+		Namespace: routeCreate.GetNamespace(),
+	}, routeCur)
+	if meta.IsNoMatchError(err) {
+		r.log.Info("Skipping HTTPRoute, the Gateway API is not installed", "HTTPRoute.Name", routeCreate.GetName())
+		return nil
+	}
+	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(routeCreate)
+		if err = controllerutil.SetControllerReference(c, routeCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), routeCreate); err == nil {
+			r.log.Info("Create HTTPRoute success",
+				"HTTPRoute.Namespace", c.Namespace,
+				"HTTPRoute.Name", routeCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if r.detectDrift(c, routeCreate, routeCur) {
+			return nil
+		}
+		sync.SyncHTTPRoute(routeCur, routeCreate)
+		if err = r.client.Update(context.TODO(), routeCur); err == nil {
+			r.log.Info("Update HTTPRoute success")
+		}
+	}
+	return
+}
+
+// reconcileRoute shall reconcile the openshift route
+func (r *ReconcileDruid) reconcileRoute(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, routeCreate *unstructured.Unstructured) (err error) {
+	routeCur := &unstructured.Unstructured{}
+	routeCur.SetGroupVersionKind(routeCreate.GroupVersionKind())
+	err = r.client.Get(context.TODO(), types.NamespacedName{
+		Name:      routeCreate.GetName(),
+		Namespace: routeCreate.GetNamespace(),
+	}, routeCur)
+	if meta.IsNoMatchError(err) {
+		r.log.Info("Skipping Route, the Route api is only served by OpenShift", "Route.Name", routeCreate.GetName())
+		return nil
+	}
+	if err != nil && errors.IsNotFound(err) {
+		annotateRendered(routeCreate)
+		if err = controllerutil.SetControllerReference(c, routeCreate, r.scheme); err != nil {
+			return err
+		}
+
+		if err = r.client.Create(context.TODO(), routeCreate); err == nil {
+			r.log.Info("Create Route success",
+				"Route.Namespace", c.Namespace,
+				"Route.Name", routeCreate.GetName())
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if r.detectDrift(c, routeCreate, routeCur) {
+			return nil
+		}
+		sync.SyncRoute(routeCur, routeCreate)
+		if err = r.client.Update(context.TODO(), routeCur); err == nil {
+			r.log.Info("Update Route success")
+		}
+	}
+	return
+}
+
+// updateDruidStatus shall persist the status collected while reconciling
+func (r *ReconcileDruid) updateDruidStatus(c *binaryomenv1alpha1.Druid, status *binaryomenv1alpha1.DruidStatus) (err error) {
+	if reflect.DeepEqual(status, &c.Status) {
+		return nil
+	}
+	err = r.client.Status().Update(context.TODO(), c)
+	if err != nil {
+		return err
+	}
+	r.log.Info("Update Druid status success")
+	return nil
+}
+
 // upateStatefulset shall sync fountsts with curr sts state
 func (r *ReconcileDruid) updateStatefulSet(foundSts *appsv1.StatefulSet, sts *appsv1.StatefulSet) (err error) {
 	r.log.Info("Updating StatefulSet",
@@ -358,11 +757,11 @@ func (r *ReconcileDruid) updateService(c *binaryomenv1alpha1.Druid, foundSvc *v1
 	return nil
 }
 
-// updateService shall sync the service
-func (r *ReconcileDruid) updateIng(c *binaryomenv1alpha1.Druid, foundIng *extensions.Ingress, ing *extensions.Ingress) (err error) {
+// updateIng shall sync the ingress
+func (r *ReconcileDruid) updateIng(c *binaryomenv1alpha1.Druid, foundIng *unstructured.Unstructured, ing *unstructured.Unstructured) (err error) {
 	r.log.Info("Updating Ingress",
-		"Ingress.Namespace", foundIng.Namespace,
-		"Ingress.Name", foundIng.Name)
+		"Ingress.Namespace", foundIng.GetNamespace(),
+		"Ingress.Name", foundIng.GetName())
 	sync.SyncIngress(foundIng, ing)
 	err = r.client.Update(context.TODO(), foundIng)
 	if err != nil {
@@ -407,6 +806,37 @@ func getAllNodeSpecsInDruidPrescribedOrder(c *binaryomenv1alpha1.Druid) ([]keyAn
 	return allNodeSpecs, nil
 }
 
+// getTierStatus groups the historical nodes by the tier they announce, highest priority first
+func getTierStatus(allNodeSpecs []keyAndNodeSpec) []binaryomenv1alpha1.TierStatus {
+	tiers := []binaryomenv1alpha1.TierStatus{}
+	for _, elem := range allNodeSpecs {
+		if elem.spec.NodeType != historical {
+			continue
+		}
+		name, priority := nodes.GetTier(&elem.spec)
+		i := 0
+		for i < len(tiers) && tiers[i].Name != name {
+			i++
+		}
+		if i == len(tiers) {
+			tiers = append(tiers, binaryomenv1alpha1.TierStatus{Name: name, Priority: priority})
+		}
+		tiers[i].Nodes = append(tiers[i].Nodes, elem.spec.Name)
+		tiers[i].Replicas += elem.spec.Replicas
+	}
+
+	sort.SliceStable(tiers, func(i, j int) bool {
+		if tiers[i].Priority != tiers[j].Priority {
+			return tiers[i].Priority > tiers[j].Priority
+		}
+		return tiers[i].Name < tiers[j].Name
+	})
+	if len(tiers) == 0 {
+		return nil
+	}
+	return tiers
+}
+
 func (r *ReconcileDruid) isDeploymentRunning(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (err error) {
 	d := &appsv1.Deployment{}
 	err = r.client.Get(context.TODO(), types.NamespacedName{
@@ -0,0 +1,163 @@
+package druid
+
+import (
+	"reflect"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// nodeObjects are the resources of a node, those whose api is not served or which the node does not use are nil
+type nodeObjects struct {
+	configMap       *v1.ConfigMap
+	commonConfigMap *v1.ConfigMap
+	headlessService *v1.Service
+	statefulSet     *appsv1.StatefulSet
+	deployment      *appsv1.Deployment
+	service         *v1.Service
+	ingress         *unstructured.Unstructured
+	httpRoute       *unstructured.Unstructured
+	route           *unstructured.Unstructured
+	networkPolicy   *networkingv1.NetworkPolicy
+	pdb             *v1beta1.PodDisruptionBudget
+}
+
+// makeNodeObjects builds the resources of a node on a cluster serving caps, both reconciled by the controller
+// and rendered by RenderManifests. The other resources are still built when the pdb cannot be
+func makeNodeObjects(ns *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, caps *capabilities.Capabilities) (*nodeObjects, error) {
+	o := &nodeObjects{
+		configMap:       nodes.MakeConfigMapNode(ns, c),
+		commonConfigMap: nodes.MakeConfigMapCommon(ns, c),
+		service:         nodes.MakeService(ns, c),
+	}
+	// statefulsets for historicals and middlemanagers, behind their governing headless service
+	if ns.NodeType == historical || ns.NodeType == middleManager {
+		o.headlessService = nodes.MakeHeadlessService(ns, c)
+		o.statefulSet = nodes.MakeStatefulSet(ns, c)
+		if !caps.TopologySpreadConstraints {
+			nodes.SpreadWithAntiAffinity(&o.statefulSet.Spec.Template)
+		}
+	}
+	// deployments for overlord, router, broker and coordinator
+	if ns.NodeType == overlord || ns.NodeType == router || ns.NodeType == broker || ns.NodeType == coordinator {
+		o.deployment = nodes.MakeDeployment(ns, c)
+		if !caps.TopologySpreadConstraints {
+			nodes.SpreadWithAntiAffinity(&o.deployment.Spec.Template)
+		}
+	}
+	if ns.Ingress.Enabled && caps.IngressAPIVersion != "" {
+		o.ingress = nodes.MakeDruidIngress(ns, c, caps.IngressAPIVersion)
+	}
+	if ns.Gateway != nil && caps.HTTPRouteAPIVersion != "" {
+		o.httpRoute = nodes.MakeHTTPRoute(ns, c, caps.HTTPRouteAPIVersion)
+	}
+	if ns.Route != nil && caps.RouteAPIVersion != "" {
+		o.route = nodes.MakeRoute(ns, c, caps.RouteAPIVersion)
+	}
+	if nodes.IsNetworkPolicyEnabled(c) {
+		o.networkPolicy = nodes.MakeNetworkPolicy(ns, c)
+	}
+	if ns.PodDisruptionBudget {
+		pdb, err := nodes.MakePodDisruptionBudget(ns, c)
+		if err != nil {
+			return o, err
+		}
+		o.pdb = pdb
+	}
+	return o, nil
+}
+
+// podTemplate returns the pod template of the statefulset or deployment of the node, if any
+func (o *nodeObjects) podTemplate() *v1.PodTemplateSpec {
+	if o.statefulSet != nil {
+		return &o.statefulSet.Spec.Template
+	}
+	if o.deployment != nil {
+		return &o.deployment.Spec.Template
+	}
+	return nil
+}
+
+// list returns the resources of the node in the order they are reconciled
+func (o *nodeObjects) list() []runtime.Object {
+	objects := []runtime.Object{}
+	for _, obj := range []runtime.Object{
+		o.configMap, o.commonConfigMap, o.headlessService, o.statefulSet, o.deployment, o.service,
+		o.ingress, o.httpRoute, o.route, o.networkPolicy, o.pdb,
+	} {
+		if obj != nil && !reflect.ValueOf(obj).IsNil() {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// RenderManifests returns the resources the operator creates for a Druid CR on a cluster serving caps,
+// in the order they are reconciled, with their kind and apiVersion set
+func RenderManifests(c *binaryomenv1alpha1.Druid, caps *capabilities.Capabilities) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+
+	if nodes.IsZookeeperManaged(c) {
+		objects = append(objects,
+			nodes.MakeZookeeperStatefulSet(c),
+			nodes.MakeZookeeperService(c),
+			nodes.MakeZookeeperPodDisruptionBudget(c),
+		)
+		if nodes.IsNetworkPolicyEnabled(c) {
+			objects = append(objects, nodes.MakeZookeeperNetworkPolicy(c))
+		}
+	}
+
+	if c.Spec.Discovery == binaryomenv1alpha1.DiscoveryKubernetes {
+		objects = append(objects,
+			nodes.MakeServiceAccount(c),
+			nodes.MakeRole(c),
+			nodes.MakeRoleBinding(c),
+		)
+	}
+
+	allNodeSpecs, err := getAllNodeSpecsInDruidPrescribedOrder(c)
+	if err != nil {
+		return nil, err
+	}
+	for i, elem := range allNodeSpecs {
+		ns := elem.spec
+		o, err := makeNodeObjects(&ns, c, caps)
+		if err != nil {
+			return nil, err
+		}
+		// the common configmap is shared by every node
+		if i > 0 {
+			o.commonConfigMap = nil
+		}
+		objects = append(objects, o.list()...)
+	}
+
+	if nodes.IsMonitoringEnabled(c) {
+		if apiVersion := caps.MonitorAPIVersion(nodes.GetMonitorKind(c)); apiVersion != "" {
+			objects = append(objects, nodes.MakeMonitor(c, apiVersion))
+		}
+	}
+
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		if accessor, err := meta.Accessor(obj); err == nil && accessor.GetNamespace() == "" {
+			accessor.SetNamespace(c.Namespace)
+		}
+	}
+	return objects, nil
+}
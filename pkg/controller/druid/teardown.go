@@ -0,0 +1,160 @@
+package druid
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/druidapi"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// teardownDruid suspends the supervisors, waits for the running tasks until the teardown timeout
+// and scales the nodes down in reverse of the prescribed order, it returns true once every node is gone
+func (r *ReconcileDruid) teardownDruid(c *binaryomenv1alpha1.Druid) (bool, error) {
+	if c.Status.Teardown == nil {
+		c.Status.Teardown = &binaryomenv1alpha1.TeardownStatus{Phase: binaryomenv1alpha1.TeardownSuspendingSupervisors}
+	}
+	teardown := c.Status.Teardown
+	if c.Status.Hibernation != nil && teardown.Phase != binaryomenv1alpha1.TeardownScalingDown {
+		// supervisors were suspended when hibernating
+		teardown.Phase = binaryomenv1alpha1.TeardownScalingDown
+	}
+
+	if teardown.Phase != binaryomenv1alpha1.TeardownScalingDown {
+		if time.Now().After(c.DeletionTimestamp.Add(getTeardownTimeout(c))) {
+			r.log.Info("Teardown timeout expired, scaling down", "Phase", teardown.Phase, "RunningTasks", teardown.RunningTasks)
+			teardown.Phase = binaryomenv1alpha1.TeardownScalingDown
+		} else if client, ok, err := r.newDruidClient(c); err != nil {
+			return false, err
+		} else if ok {
+			if teardown.Phase == binaryomenv1alpha1.TeardownSuspendingSupervisors {
+				if _, err := r.suspendSupervisors(client); err != nil {
+					return false, err
+				}
+				teardown.Phase = binaryomenv1alpha1.TeardownWaitingForTasks
+			}
+			tasks, err := client.ListRunningTasks()
+			if err != nil {
+				return false, err
+			}
+			teardown.RunningTasks = int32(len(tasks))
+			if len(tasks) > 0 {
+				r.log.Info("Waiting for running tasks", "RunningTasks", len(tasks))
+				return false, nil
+			}
+			teardown.Phase = binaryomenv1alpha1.TeardownScalingDown
+		} else {
+			teardown.Phase = binaryomenv1alpha1.TeardownScalingDown
+		}
+	}
+
+	allNodeSpecs, err := getAllNodeSpecsInDruidPrescribedOrder(c)
+	if err != nil {
+		// nodes of an invalid spec are left to the garbage collector
+		r.log.Error(err, "Scaling down Druid nodes Error", "name", c.Name)
+		return true, nil
+	}
+	for i := len(allNodeSpecs) - 1; i >= 0; i-- {
+		ns := allNodeSpecs[i].spec
+		scaled, err := r.scaleDownNode(&ns, c)
+		if err != nil || !scaled {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// suspendSupervisors suspends the running supervisors and returns their ids
+func (r *ReconcileDruid) suspendSupervisors(client *druidapi.Client) ([]string, error) {
+	supervisors, err := client.ListSupervisors()
+	if err != nil {
+		return nil, err
+	}
+	suspended := []string{}
+	for _, s := range supervisors {
+		if s.Suspended {
+			continue
+		}
+		if guard, ok := r.client.(*guardedClient); ok {
+			guard.recordAction(fmt.Sprintf("Suspend Supervisor %s", s.ID))
+			continue
+		}
+		if err = client.SuspendSupervisor(s.ID); err != nil {
+			return suspended, err
+		}
+		r.log.Info("Suspend supervisor success", "Supervisor", s.ID)
+		suspended = append(suspended, s.ID)
+	}
+	return suspended, nil
+}
+
+// scaleDownNode scales the statefulset or deployment of a node to zero, it returns true once no pod is left
+func (r *ReconcileDruid) scaleDownNode(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (bool, error) {
+	var zero int32
+	name := types.NamespacedName{Name: nodes.MakeStatefulSet(cc, c).Name, Namespace: c.Namespace}
+
+	if cc.NodeType == historical || cc.NodeType == middleManager {
+		sts := &appsv1.StatefulSet{}
+		if err := r.client.Get(context.TODO(), name, sts); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		if *sts.Spec.Replicas != zero {
+			sts.Spec.Replicas = &zero
+			if err := r.client.Update(context.TODO(), sts); err != nil {
+				return false, err
+			}
+			r.log.Info("Scale down statefulSet success", "StatefulSet.Name", sts.Name)
+		}
+		return sts.Status.Replicas == 0, nil
+	}
+
+	d := &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), name, d); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if *d.Spec.Replicas != zero {
+		d.Spec.Replicas = &zero
+		if err := r.client.Update(context.TODO(), d); err != nil {
+			return false, err
+		}
+		r.log.Info("Scale down deployment success", "Deployment.Name", d.Name)
+	}
+	return d.Status.Replicas == 0, nil
+}
+
+// getOverlordURL returns the url of the overlord, or of a coordinator running as overlord
+func getOverlordURL(c *binaryomenv1alpha1.Druid) (string, bool) {
+	allNodeSpecs, err := getAllNodeSpecsInDruidPrescribedOrder(c)
+	if err != nil {
+		return "", false
+	}
+	for _, elem := range allNodeSpecs {
+		if elem.spec.NodeType == overlord {
+			return nodes.GetServiceURL(&elem.spec, c), true
+		}
+	}
+	for _, elem := range allNodeSpecs {
+		if asOverlord, _ := nodes.GetNodeProperty(&elem.spec, "druid.coordinator.asOverlord.enabled"); elem.spec.NodeType == coordinator && asOverlord == "true" {
+			return nodes.GetServiceURL(&elem.spec, c), true
+		}
+	}
+	return "", false
+}
+
+func getTeardownTimeout(c *binaryomenv1alpha1.Druid) time.Duration {
+	if c.Spec.TeardownTimeout != nil {
+		return c.Spec.TeardownTimeout.Duration
+	}
+	return defaultTeardownTimeout
+}
@@ -0,0 +1,202 @@
+package druid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/certs"
+	"github.com/BinaryOmen/druid-operator/pkg/druidapi"
+	nodes "github.com/BinaryOmen/druid-operator/pkg/nodes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	caValidity = 10 * 365 * 24 * time.Hour
+	caCertKey  = "ca.crt"
+)
+
+// reconcileTLS shall create the keystore password, the cluster CA and the node certificates,
+// node certificates are reissued before they expire and the pods rolled through their checksum
+func (r *ReconcileDruid) reconcileTLS(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) error {
+	if c.Spec.TLS == nil {
+		return nil
+	}
+	if err := r.reconcileKeystorePassword(c); err != nil {
+		return err
+	}
+	if c.Spec.TLS.SecretName != "" {
+		return nil
+	}
+
+	ca, err := r.reconcileCA(c)
+	if err != nil || ca == nil {
+		return err
+	}
+	allNodeSpecs, err := getAllNodeSpecsInDruidPrescribedOrder(c)
+	if err != nil {
+		return err
+	}
+	for _, elem := range allNodeSpecs {
+		if err := r.reconcileNodeCertificate(&elem.spec, c, ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileDruid) reconcileKeystorePassword(c *binaryomenv1alpha1.Druid) error {
+	cur := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodes.MakeKeystorePasswordSecretName(c), Namespace: c.Namespace}, cur)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	password, err := generatePassword()
+	if err != nil {
+		return err
+	}
+	return r.createSecret(c, nodes.MakeKeystorePasswordSecret(c, password))
+}
+
+// reconcileCA returns the cluster CA secret, it is created once and never rotated
+func (r *ReconcileDruid) reconcileCA(c *binaryomenv1alpha1.Druid) (*v1.Secret, error) {
+	cur := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodes.MakeCASecretName(c), Namespace: c.Namespace}, cur)
+	if err == nil {
+		return cur, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	caCert, caKey, err := certs.NewCA(fmt.Sprintf("%s-ca", c.Name), caValidity)
+	if err != nil {
+		return nil, err
+	}
+	ca := nodes.MakeTLSSecret(nodes.MakeCASecretName(c), c, caCert, caCert, caKey)
+	if err := r.createSecret(c, ca); err != nil {
+		return nil, err
+	}
+	if _, ok := r.client.(*guardedClient); ok {
+		// nothing can be issued by a CA which was not created
+		return nil, nil
+	}
+	return ca, nil
+}
+
+// reconcileNodeCertificate issues the certificate of a node when missing, expiring, not issued by the
+// cluster CA or not matching the node dns names
+func (r *ReconcileDruid) reconcileNodeCertificate(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, ca *v1.Secret) error {
+	name := nodes.GetNodeTLSSecretName(cc, c)
+	dnsNames := nodes.GetNodeDNSNames(cc, c)
+
+	cur := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: c.Namespace}, cur)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+	if found && !needsRenewal(cur, ca, dnsNames, nodes.GetRenewBefore(c)) {
+		return nil
+	}
+
+	cert, key, err := certs.NewCertificate(ca.Data[v1.TLSCertKey], ca.Data[v1.TLSPrivateKeyKey], cc.Name, dnsNames, nodes.GetCertValidity(c))
+	if err != nil {
+		return err
+	}
+	secret := nodes.MakeTLSSecret(name, c, ca.Data[v1.TLSCertKey], cert, key)
+	if !found {
+		return r.createSecret(c, secret)
+	}
+	cur.Data = secret.Data
+	if err := r.client.Update(context.TODO(), cur); err != nil {
+		return err
+	}
+	r.log.Info("Renew node certificate success", "Secret.Name", name)
+	return nil
+}
+
+func (r *ReconcileDruid) createSecret(c *binaryomenv1alpha1.Druid, secret *v1.Secret) error {
+	if err := controllerutil.SetControllerReference(c, secret, r.scheme); err != nil {
+		return err
+	}
+	if err := r.client.Create(context.TODO(), secret); err != nil {
+		return err
+	}
+	r.log.Info("Create secret success", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+	return nil
+}
+
+// setTLSChecksum annotates the pod template with a checksum of the node certificate, a renewed
+// certificate rolls the pods which build their keystores at start
+func (r *ReconcileDruid) setTLSChecksum(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, tpl *v1.PodTemplateSpec) error {
+	if c.Spec.TLS == nil {
+		return nil
+	}
+	secret := &v1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodes.GetNodeTLSSecretName(cc, c), Namespace: c.Namespace}, secret)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// the pods wait for the secret, they are rolled once it is issued
+			return nil
+		}
+		return err
+	}
+	h := sha256.New()
+	h.Write(secret.Data[caCertKey])
+	h.Write(secret.Data[v1.TLSCertKey])
+	nodes.SetPodTemplateAnnotation(tpl, nodes.TLSChecksumAnnotation, hex.EncodeToString(h.Sum(nil))[:16])
+	return nil
+}
+
+// newDruidClient returns a client for the overlord of the cluster, trusting the cluster CA when TLS is enabled
+// and authenticated as admin when basic-security is
+func (r *ReconcileDruid) newDruidClient(c *binaryomenv1alpha1.Druid) (*druidapi.Client, bool, error) {
+	url, ok := getOverlordURL(c)
+	if !ok {
+		return nil, false, nil
+	}
+	client := druidapi.NewClient(url)
+	if c.Spec.TLS != nil {
+		name := c.Spec.TLS.SecretName
+		if name == "" {
+			name = nodes.MakeCASecretName(c)
+		}
+		secret := &v1.Secret{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: c.Namespace}, secret); err != nil {
+			return nil, false, err
+		}
+		var err error
+		if client, err = druidapi.NewTLSClient(url, secret.Data[caCertKey]); err != nil {
+			return nil, false, err
+		}
+	}
+	if nodes.IsAuthenticationEnabled(c) {
+		user, password, err := r.getAdminCredentials(c)
+		if err != nil {
+			return nil, false, err
+		}
+		client.SetBasicAuth(user, password)
+	}
+	return client, true, nil
+}
+
+func needsRenewal(secret *v1.Secret, ca *v1.Secret, dnsNames []string, renewBefore time.Duration) bool {
+	cert, err := certs.ParseCertificate(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return true
+	}
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return true
+	}
+	if !certs.IssuedBy(secret.Data[v1.TLSCertKey], ca.Data[v1.TLSCertKey]) {
+		return true
+	}
+	return !reflect.DeepEqual(cert.DNSNames, dnsNames)
+}
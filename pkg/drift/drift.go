@@ -0,0 +1,91 @@
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Compare returns the paths of the fields set on the desired object whose live value differs.
+// Fields left unset on the desired object are skipped, so values defaulted by the api server are not
+// reported, neither are the status and the metadata other than labels and annotations
+func Compare(desired runtime.Object, live runtime.Object) ([]string, error) {
+	d, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, err
+	}
+	l, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{}
+	for _, key := range sortedKeys(d) {
+		switch key {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			dm, _ := d[key].(map[string]interface{})
+			lm, _ := l[key].(map[string]interface{})
+			compare("metadata.labels", dm["labels"], lm["labels"], &fields)
+			compare("metadata.annotations", dm["annotations"], lm["annotations"], &fields)
+		default:
+			compare(key, d[key], l[key], &fields)
+		}
+	}
+	return fields, nil
+}
+
+// Hash identifies a rendered object, a live object carrying the hash of the current rendering
+// was not changed by the operator since, any difference is drift
+func Hash(obj runtime.Object) (string, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))[:16], nil
+}
+
+func compare(path string, desired interface{}, live interface{}, fields *[]string) {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(d) > 0 {
+				*fields = append(*fields, path)
+			}
+			return
+		}
+		for _, key := range sortedKeys(d) {
+			compare(fmt.Sprintf("%s.%s", path, key), d[key], l[key], fields)
+		}
+	case []interface{}:
+		l, _ := live.([]interface{})
+		if len(d) != len(l) {
+			*fields = append(*fields, path)
+			return
+		}
+		for i := range d {
+			compare(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], fields)
+		}
+	default:
+		if desired == nil || reflect.ValueOf(desired).IsZero() {
+			return
+		}
+		if !reflect.DeepEqual(desired, live) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+package druidapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Client talks to the overlord of a druid cluster
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	user     string
+	password string
+}
+
+// Supervisor is a supervisor returned by the overlord
+type Supervisor struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Suspended bool   `json:"suspended"`
+}
+
+// Task is a task returned by the overlord
+type Task struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	DataSource string `json:"dataSource"`
+}
+
+// NewClient returns a client for the overlord listening on baseURL, eg http://overlord.druid.svc:8090
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewTLSClient returns a client for the overlord listening on an https baseURL, trusting the PEM CA
+func NewTLSClient(baseURL string, caPEM []byte) (*Client, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("invalid CA certificate")
+	}
+	return &Client{
+		baseURL: baseURL,
+		http: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}, nil
+}
+
+// SetBasicAuth authenticates the requests against the druid basic authenticator
+func (c *Client) SetBasicAuth(user string, password string) {
+	c.user = user
+	c.password = password
+}
+
+// ListSupervisors returns the supervisors with their state
+func (c *Client) ListSupervisors() ([]Supervisor, error) {
+	supervisors := []Supervisor{}
+	if err := c.do(http.MethodGet, "/druid/indexer/v1/supervisor?state=true", &supervisors); err != nil {
+		return nil, err
+	}
+	return supervisors, nil
+}
+
+// SuspendSupervisor stops the tasks of a supervisor until it is resumed
+func (c *Client) SuspendSupervisor(id string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/druid/indexer/v1/supervisor/%s/suspend", url.PathEscape(id)), nil)
+}
+
+// ResumeSupervisor restarts the tasks of a suspended supervisor
+func (c *Client) ResumeSupervisor(id string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/druid/indexer/v1/supervisor/%s/resume", url.PathEscape(id)), nil)
+}
+
+// ListRunningTasks returns the tasks currently running
+func (c *Client) ListRunningTasks() ([]Task, error) {
+	tasks := []Task{}
+	if err := c.do(http.MethodGet, "/druid/indexer/v1/runningTasks", &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *Client) do(method string, path string, out interface{}) error {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status [%d]: %s", method, path, resp.StatusCode, string(body))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
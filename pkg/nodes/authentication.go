@@ -0,0 +1,113 @@
+package nodes
+
+import (
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AdminUser is the initial admin of the basic authenticator
+	AdminUser = "admin"
+	// InternalClientUser is the user druid nodes authenticate to each other with
+	InternalClientUser = "druid_system"
+	// AdminPasswordKey holds the admin password in the credentials Secret
+	AdminPasswordKey = "admin-password"
+	// InternalClientPasswordKey holds the internal client password in the credentials Secret
+	InternalClientPasswordKey = "internal-client-password"
+
+	adminPasswordEnv          = "DRUID_ADMIN_PASSWORD"
+	internalClientPasswordEnv = "DRUID_INTERNAL_CLIENT_PASSWORD"
+	defaultAuthenticatorName  = "basic"
+)
+
+// IsAuthenticationEnabled reports whether druid-basic-security is managed by the operator
+func IsAuthenticationEnabled(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.Authentication != nil && c.Spec.Authentication.Enabled
+}
+
+// MakeCredentialsSecretName returns the name of the Secret holding the generated passwords
+func MakeCredentialsSecretName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("%s-credentials", c.Name)
+}
+
+// MakeCredentialsSecret holds the admin and internal client passwords
+func MakeCredentialsSecret(c *binaryomenv1alpha1.Druid, adminPassword string, internalClientPassword string) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MakeCredentialsSecretName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+		Data: map[string][]byte{
+			AdminPasswordKey:          []byte(adminPassword),
+			InternalClientPasswordKey: []byte(internalClientPassword),
+		},
+	}
+}
+
+func getAuthenticatorName(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Authentication.Name != "" {
+		return c.Spec.Authentication.Name
+	}
+	return defaultAuthenticatorName
+}
+
+// getAuthenticationProperties configures the basic authenticator, escalator and authorizer, passwords are
+// read from the environment so they never appear in the configmaps
+func getAuthenticationProperties(c *binaryomenv1alpha1.Druid) []property {
+	if !IsAuthenticationEnabled(c) {
+		return nil
+	}
+	name := getAuthenticatorName(c)
+	authenticator := fmt.Sprintf("druid.auth.authenticator.%s", name)
+	return []property{
+		{key: "druid.auth.authenticatorChain", value: fmt.Sprintf(`["%s"]`, name)},
+		{key: authenticator + ".type", value: "basic"},
+		{key: authenticator + ".initialAdminPassword", value: makePasswordProvider(adminPasswordEnv)},
+		{key: authenticator + ".initialInternalClientPassword", value: makePasswordProvider(internalClientPasswordEnv)},
+		{key: authenticator + ".credentialsValidator.type", value: "metadata"},
+		{key: authenticator + ".skipOnFailure", value: "false"},
+		{key: authenticator + ".authorizerName", value: name},
+		{key: "druid.escalator.type", value: "basic"},
+		{key: "druid.escalator.internalClientUsername", value: InternalClientUser},
+		{key: "druid.escalator.internalClientPassword", value: makePasswordProvider(internalClientPasswordEnv)},
+		{key: "druid.escalator.authorizerName", value: name},
+		{key: "druid.auth.authorizers", value: fmt.Sprintf(`["%s"]`, name)},
+		{key: fmt.Sprintf("druid.auth.authorizer.%s.type", name), value: "basic"},
+	}
+}
+
+func getAuthenticationEnv(c *binaryomenv1alpha1.Druid) []v1.EnvVar {
+	if !IsAuthenticationEnabled(c) {
+		return nil
+	}
+	return []v1.EnvVar{
+		makeSecretEnv(adminPasswordEnv, MakeCredentialsSecretName(c), AdminPasswordKey),
+		makeSecretEnv(internalClientPasswordEnv, MakeCredentialsSecretName(c), InternalClientPasswordKey),
+	}
+}
+
+func makePasswordProvider(env string) string {
+	return fmt.Sprintf(`{"type":"environment","variable":"%s"}`, env)
+}
+
+func makeSecretEnv(name string, secret string, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
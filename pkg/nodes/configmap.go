@@ -20,7 +20,7 @@ func MakeConfigMapNode(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Dr
 			Namespace: c.Namespace,
 		},
 		Data: map[string]string{
-			"runtime.properties": fmt.Sprintf("%s", cc.RuntimeProperties),
+			"runtime.properties": fmt.Sprintf("%s", getRuntimeProperties(cc, c)),
 			"jvm.options":        fmt.Sprintf("%s", getJVM(cc, c)),
 			"log4j2.xml":         fmt.Sprintf("%s", getLog4jConfig(cc, c)),
 		},
@@ -38,20 +38,71 @@ func MakeConfigMapCommon(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.
 			Namespace: c.Namespace,
 		},
 		Data: map[string]string{
-			"common.runtime.properties": fmt.Sprintf("%s", c.Spec.CommonRuntimeProperties),
+			"common.runtime.properties": fmt.Sprintf("%s", getCommonRuntimeProperties(c)),
 		},
 	}
 }
 
+// getCommonRuntimeProperties appends the operator generated properties to CommonRuntimeProperties
+func getCommonRuntimeProperties(c *binaryomenv1alpha1.Druid) string {
+	props := c.Spec.CommonRuntimeProperties
+	generated := []property{}
+
+	if loadList, ok := getExtensionsLoadList(c, true); ok {
+		props = removeProperty(props, loadListKey)
+		generated = append(generated, makeLoadListProperty(loadList))
+	}
+	generated = append(generated, getDiscoveryProperties(c)...)
+	generated = append(generated, getZookeeperProperties(c)...)
+	generated = append(generated, getTLSProperties(c)...)
+	generated = append(generated, getAuthenticationProperties(c)...)
+	generated = append(generated, getMonitoringProperties(c)...)
+
+	return appendProperties(props, generated)
+}
+
+// getRuntimeProperties appends the operator generated properties to the node RuntimeProperties
+func getRuntimeProperties(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) string {
+	generated := []property{}
+
+	// nodes skipping pull-deps override the common loadList with the extensions bundled in the image
+	if c.Spec.Extensions != nil && len(c.Spec.Extensions.Coordinates) > 0 && cc.SkipExtensions {
+		loadList, _ := getExtensionsLoadList(c, false)
+		generated = append(generated, makeLoadListProperty(loadList))
+	}
+
+	generated = append(generated, getNodeProperties(cc)...)
+	generated = append(generated, getMemoryProperties(cc)...)
+	generated = append(generated, getTierProperties(cc)...)
+	generated = append(generated, getSegmentCacheProperties(cc)...)
+	generated = append(generated, getPeonMonitoringProperties(cc, c)...)
+
+	return appendProperties(cc.RuntimeProperties, generated)
+}
+
+// getNodeProperties derives the service name and port a node announces from its spec, druid.host is
+// set through JAVA_OPTS since the pod ip is only known at runtime
+func getNodeProperties(cc *binaryomenv1alpha1.NodeSpec) []property {
+	props := []property{
+		{key: "druid.service", value: fmt.Sprintf("druid/%s", cc.NodeType)},
+	}
+	return append(props, getPortProperties(cc)...)
+}
+
+// GetNodeProperty returns the value of key in the node RuntimeProperties
+func GetNodeProperty(cc *binaryomenv1alpha1.NodeSpec, key string) (string, bool) {
+	return getProperty(cc.RuntimeProperties, key)
+}
+
 func makeConfigMapName(cc *binaryomenv1alpha1.NodeSpec) string {
 	return fmt.Sprintf("%s", cc.Name)
 }
 
 func getJVM(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) string {
 	if cc.JvmOptions != "" {
-		return cc.JvmOptions
+		return applyMemoryModel(cc, cc.JvmOptions)
 	} else {
-		return c.Spec.JvmOptions
+		return applyMemoryModel(cc, c.Spec.JvmOptions)
 	}
 }
 
@@ -0,0 +1,143 @@
+package nodes
+
+import (
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	podNameEnv      = "POD_NAME"
+	podNamespaceEnv = "POD_NAMESPACE"
+)
+
+// getDiscoveryProperties switches the cluster to the kubernetes discovery extension, which requires
+// http based segment management and task running since zookeeper is disabled
+func getDiscoveryProperties(c *binaryomenv1alpha1.Druid) []property {
+	if c.Spec.Discovery != binaryomenv1alpha1.DiscoveryKubernetes {
+		return nil
+	}
+	return []property{
+		{key: "druid.zk.service.enabled", value: "false"},
+		{key: "druid.discovery.type", value: "k8s"},
+		{key: "druid.discovery.k8s.clusterIdentifier", value: c.Name},
+		{key: "druid.discovery.k8s.podNameEnvKey", value: podNameEnv},
+		{key: "druid.discovery.k8s.podNamespaceEnvKey", value: podNamespaceEnv},
+		{key: "druid.serverview.type", value: "http"},
+		{key: "druid.coordinator.loadqueuepeon.type", value: "http"},
+		{key: "druid.indexer.runner.type", value: "httpRemote"},
+	}
+}
+
+// getDiscoveryEnv exposes the pod name and namespace the kubernetes discovery extension
+// uses to patch its announcement labels and annotations onto its own pod
+func getDiscoveryEnv(c *binaryomenv1alpha1.Druid) []v1.EnvVar {
+	if c.Spec.Discovery != binaryomenv1alpha1.DiscoveryKubernetes {
+		return nil
+	}
+	return []v1.EnvVar{
+		{
+			Name: podNameEnv,
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		{
+			Name: podNamespaceEnv,
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		},
+	}
+}
+
+func getServiceAccountName(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Discovery != binaryomenv1alpha1.DiscoveryKubernetes {
+		return ""
+	}
+	return makeDiscoveryName(c)
+}
+
+func makeDiscoveryName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("druid-%s", c.Name)
+}
+
+// MakeServiceAccount for druid pods using the kubernetes discovery
+func MakeServiceAccount(c *binaryomenv1alpha1.Druid) *v1.ServiceAccount {
+	return &v1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeDiscoveryName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+	}
+}
+
+// MakeRole grants druid pods the permissions of the kubernetes discovery, pods announce themselves
+// by patching druidDiscoveryAnnouncement-* labels and druidNodeInfo-* annotations onto their own pod
+// and coordinator/overlord leaders are elected through configmaps
+func MakeRole(c *binaryomenv1alpha1.Druid) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeDiscoveryName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list", "watch", "patch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+		},
+	}
+}
+
+// MakeRoleBinding binds the discovery role to the druid service account
+func MakeRoleBinding(c *binaryomenv1alpha1.Druid) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeDiscoveryName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      makeDiscoveryName(c),
+				Namespace: c.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     makeDiscoveryName(c),
+		},
+	}
+}
@@ -0,0 +1,179 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	loadListKey                = "druid.extensions.loadList"
+	extensionsVolumeName       = "extensions"
+	defaultExtensionsMountPath = "/opt/druid/extensions-pulled"
+	druidClassPath             = "/opt/druid/lib/*"
+)
+
+// pullDepsScript runs pull-deps unless the volume already holds the requested coordinates,
+// pulled extensions survive container restarts and, on persistent volumes, pod restarts
+const pullDepsScript = `set -e
+if [ "$(cat "$EXTENSIONS_DIR/.coordinates" 2>/dev/null)" = "$EXTENSIONS_COORDINATES" ]; then
+  echo "extensions already pulled"
+  exit 0
+fi
+args=""
+for c in $EXTENSIONS_COORDINATES; do
+  args="$args -c $c"
+done
+java -cp "` + druidClassPath + `" \
+  -Ddruid.extensions.directory="$EXTENSIONS_DIR" \
+  -Ddruid.extensions.hadoopDependenciesDir="$EXTENSIONS_DIR/hadoop-dependencies" \
+  org.apache.druid.cli.Main tools pull-deps --no-default-hadoop $args
+echo "$EXTENSIONS_COORDINATES" > "$EXTENSIONS_DIR/.coordinates"
+`
+
+// GetExtensionArtifact returns the artifactId of a groupId:artifactId:version coordinate
+func GetExtensionArtifact(coordinate string) (string, error) {
+	parts := strings.Split(coordinate, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("invalid extension coordinate [%s], expected groupId:artifactId:version", coordinate)
+	}
+	return parts[1], nil
+}
+
+// GetUserLoadList parses druid.extensions.loadList from the common runtime properties
+func GetUserLoadList(c *binaryomenv1alpha1.Druid) ([]string, error) {
+	loadList := []string{}
+	value, ok := getProperty(c.Spec.CommonRuntimeProperties, loadListKey)
+	if !ok {
+		return loadList, nil
+	}
+	if err := json.Unmarshal([]byte(value), &loadList); err != nil {
+		return nil, fmt.Errorf("invalid %s [%s]: %v", loadListKey, value, err)
+	}
+	return loadList, nil
+}
+
+// getRequiredExtensions lists the extensions the operator generated properties depend on
+func getRequiredExtensions(c *binaryomenv1alpha1.Druid) []string {
+	required := []string{}
+	if c.Spec.Discovery == binaryomenv1alpha1.DiscoveryKubernetes {
+		required = append(required, "druid-kubernetes-extensions")
+	}
+	if c.Spec.TLS != nil {
+		required = append(required, "simple-client-sslcontext")
+	}
+	if IsAuthenticationEnabled(c) {
+		required = append(required, "druid-basic-security")
+	}
+	if IsMonitoringEnabled(c) && !pullsPrometheusEmitter(c) {
+		required = append(required, prometheusEmitterExtension)
+	}
+	return required
+}
+
+// RewritesLoadList tells whether the operator rewrites druid.extensions.loadList, which then has to be parsed
+func RewritesLoadList(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.Extensions != nil || len(getRequiredExtensions(c)) > 0
+}
+
+// getExtensionsLoadList merges the user loadList with the managed and required extensions, pulled extensions
+// are loaded from their absolute path in the extensions volume
+func getExtensionsLoadList(c *binaryomenv1alpha1.Druid, pulled bool) ([]string, bool) {
+	if !RewritesLoadList(c) {
+		return nil, false
+	}
+
+	loadList, _ := GetUserLoadList(c)
+	if c.Spec.Extensions != nil {
+		loadList = append(loadList, c.Spec.Extensions.LoadList...)
+	}
+	loadList = append(loadList, getRequiredExtensions(c)...)
+	if pulled && c.Spec.Extensions != nil {
+		for _, coordinate := range c.Spec.Extensions.Coordinates {
+			if artifact, err := GetExtensionArtifact(coordinate); err == nil {
+				loadList = append(loadList, fmt.Sprintf("%s/%s", getExtensionsMountPath(c), artifact))
+			}
+		}
+	}
+
+	return uniqueStrings(loadList), true
+}
+
+func makeLoadListProperty(loadList []string) property {
+	value, _ := json.Marshal(loadList)
+	return property{key: loadListKey, value: string(value)}
+}
+
+func getExtensionsMountPath(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Extensions.MountPath != "" {
+		return c.Spec.Extensions.MountPath
+	}
+	return defaultExtensionsMountPath
+}
+
+// pullExtensions reports whether the node runs pull-deps before starting
+func pullExtensions(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.Extensions != nil && len(c.Spec.Extensions.Coordinates) > 0 && !cc.SkipExtensions
+}
+
+func getExtensionsImage(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Extensions.Image != "" {
+		return c.Spec.Extensions.Image
+	}
+	return c.Spec.Image
+}
+
+func makePullDepsContainer(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) v1.Container {
+	return v1.Container{
+		Name:    "pull-deps",
+		Image:   getExtensionsImage(c),
+		Command: []string{"sh", "-c", pullDepsScript},
+		Env: []v1.EnvVar{
+			{
+				Name:  "EXTENSIONS_DIR",
+				Value: getExtensionsMountPath(c),
+			},
+			{
+				Name:  "EXTENSIONS_COORDINATES",
+				Value: strings.Join(c.Spec.Extensions.Coordinates, " "),
+			},
+		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "File",
+		VolumeMounts:             []v1.VolumeMount{makeExtensionsVolumeMount(c)},
+	}
+}
+
+func makeExtensionsVolume(c *binaryomenv1alpha1.Druid) v1.Volume {
+	source := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+	if c.Spec.Extensions.Volume != nil {
+		source = *c.Spec.Extensions.Volume
+	}
+	return v1.Volume{
+		Name:         extensionsVolumeName,
+		VolumeSource: source,
+	}
+}
+
+func makeExtensionsVolumeMount(c *binaryomenv1alpha1.Druid) v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      extensionsVolumeName,
+		MountPath: getExtensionsMountPath(c),
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, val := range values {
+		if seen[val] {
+			continue
+		}
+		seen[val] = true
+		unique = append(unique, val)
+	}
+	return unique
+}
@@ -0,0 +1,168 @@
+package nodes
+
+import (
+	"sort"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const defaultRoutePathType = "PathPrefix"
+
+// MakeHTTPRoute renders the HTTPRoute of a node on apiVersion, as an unstructured object since the
+// Gateway API CRDs may not be installed
+func MakeHTTPRoute(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, apiVersion string) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": getHTTPRouteSpec(cc, c),
+		},
+	}
+	route.SetAPIVersion(apiVersion)
+	route.SetKind("HTTPRoute")
+	route.SetName(cc.Name)
+	route.SetNamespace(c.Namespace)
+	route.SetLabels(map[string]string{
+		"app":  "druid",
+		"type": cc.NodeType,
+		"name": cc.Name,
+	})
+	return route
+}
+
+// GetHTTPRouteBackendPort returns the Service port the route forwards to, backendRefs only take port numbers
+func GetHTTPRouteBackendPort(cc *binaryomenv1alpha1.NodeSpec) (binaryomenv1alpha1.DruidServicePort, bool) {
+	if cc.Gateway.TargetPort != "" {
+		return GetServicePort(cc, cc.Gateway.TargetPort)
+	}
+	port, _ := getHTTPPort(cc)
+	return port, port.Port != 0
+}
+
+func getHTTPRouteSpec(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) map[string]interface{} {
+	g := cc.Gateway
+
+	parentRef := map[string]interface{}{
+		"name": g.ParentRef.Name,
+	}
+	if g.ParentRef.Namespace != "" {
+		parentRef["namespace"] = g.ParentRef.Namespace
+	}
+	if g.ParentRef.SectionName != "" {
+		parentRef["sectionName"] = g.ParentRef.SectionName
+	}
+
+	port, _ := GetHTTPRouteBackendPort(cc)
+	rule := map[string]interface{}{
+		"matches": getHTTPRouteMatches(g),
+		"backendRefs": []interface{}{
+			map[string]interface{}{
+				"name": cc.Name,
+				"port": int64(port.Port),
+			},
+		},
+	}
+	filters := []interface{}{}
+	if g.RequestHeaders != nil {
+		filters = append(filters, map[string]interface{}{
+			"type":                  "RequestHeaderModifier",
+			"requestHeaderModifier": getHeaderModifier(g.RequestHeaders),
+		})
+	}
+	if g.ResponseHeaders != nil {
+		filters = append(filters, map[string]interface{}{
+			"type":                   "ResponseHeaderModifier",
+			"responseHeaderModifier": getHeaderModifier(g.ResponseHeaders),
+		})
+	}
+	if len(filters) > 0 {
+		rule["filters"] = filters
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"rules":      []interface{}{rule},
+	}
+	if len(g.Hostnames) > 0 {
+		hostnames := []interface{}{}
+		for _, h := range g.Hostnames {
+			hostnames = append(hostnames, h)
+		}
+		spec["hostnames"] = hostnames
+	}
+	return spec
+}
+
+func getHTTPRouteMatches(g *binaryomenv1alpha1.DruidGateway) []interface{} {
+	matches := g.Matches
+	if len(matches) == 0 {
+		matches = []binaryomenv1alpha1.DruidHTTPRouteMatch{{}}
+	}
+
+	routeMatches := []interface{}{}
+	for _, m := range matches {
+		path := map[string]interface{}{
+			"type":  defaultRoutePathType,
+			"value": "/",
+		}
+		if m.PathType != "" {
+			path["type"] = m.PathType
+		}
+		if m.Path != "" {
+			path["value"] = m.Path
+		}
+		match := map[string]interface{}{
+			"path": path,
+		}
+		if len(m.Headers) > 0 {
+			headers := []interface{}{}
+			for _, name := range sortedKeys(m.Headers) {
+				headers = append(headers, map[string]interface{}{
+					"type":  "Exact",
+					"name":  name,
+					"value": m.Headers[name],
+				})
+			}
+			match["headers"] = headers
+		}
+		routeMatches = append(routeMatches, match)
+	}
+	return routeMatches
+}
+
+func getHeaderModifier(f *binaryomenv1alpha1.DruidHTTPHeaderFilter) map[string]interface{} {
+	modifier := map[string]interface{}{}
+	if len(f.Set) > 0 {
+		modifier["set"] = makeHTTPHeaders(f.Set)
+	}
+	if len(f.Add) > 0 {
+		modifier["add"] = makeHTTPHeaders(f.Add)
+	}
+	if len(f.Remove) > 0 {
+		remove := []interface{}{}
+		for _, name := range f.Remove {
+			remove = append(remove, name)
+		}
+		modifier["remove"] = remove
+	}
+	return modifier
+}
+
+func makeHTTPHeaders(values map[string]string) []interface{} {
+	headers := []interface{}{}
+	for _, name := range sortedKeys(values) {
+		headers = append(headers, map[string]interface{}{
+			"name":  name,
+			"value": values[name],
+		})
+	}
+	return headers
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
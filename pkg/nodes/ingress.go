@@ -2,67 +2,134 @@ package nodes
 
 import (
 	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
-	extensions "k8s.io/api/extensions/v1beta1"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/BinaryOmen/druid-operator/pkg/capabilities"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-func MakeDruidIngress(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *extensions.Ingress {
-	return &extensions.Ingress{
-		ObjectMeta: v1.ObjectMeta{
-			Name:      cc.Name,
-			Namespace: c.Namespace,
-			Labels: map[string]string{
-				"app":  "druid",
-				"type": cc.NodeType,
-				"name": cc.Name,
-			},
-			Annotations: getIngressAnnotations(cc),
+const (
+	defaultPathType        = "ImplementationSpecific"
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+// MakeDruidIngress renders the Ingress of a node on apiVersion, networking.k8s.io/v1 or one of the v1beta1 apis
+// served by older clusters, as an unstructured object since the client only knows the v1beta1 types
+func MakeDruidIngress(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, apiVersion string) *unstructured.Unstructured {
+	ing := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": getIngressSpec(cc, apiVersion),
 		},
-		Spec: getIngressSpec(cc),
 	}
+	ing.SetAPIVersion(apiVersion)
+	ing.SetKind("Ingress")
+	ing.SetName(cc.Name)
+	ing.SetNamespace(c.Namespace)
+	ing.SetLabels(getIngressLabels(cc))
+	ing.SetAnnotations(getIngressAnnotations(cc, apiVersion))
+	return ing
 }
 
-func getIngressTLS(cc *binaryomenv1alpha1.NodeSpec) []extensions.IngressTLS {
-	if cc.Ingress.Enabled == false {
+// GetIngressRules returns the Ingress Rules, or the rule of the Hostname and Path
+func GetIngressRules(cc *binaryomenv1alpha1.NodeSpec) []binaryomenv1alpha1.DruidIngressRule {
+	if len(cc.Ingress.Rules) > 0 {
+		return cc.Ingress.Rules
+	}
+	return []binaryomenv1alpha1.DruidIngressRule{
+		{
+			Host:  GetHost(cc),
+			Paths: []binaryomenv1alpha1.DruidIngressPath{{Path: GetPath(cc)}},
+		},
+	}
+}
+
+func getIngressTLS(cc *binaryomenv1alpha1.NodeSpec) []interface{} {
+	if cc.Ingress.Enabled == false || !cc.Ingress.TLSEnabled {
 		return nil
 	}
 
-	if cc.Ingress.TLSEnabled {
-		return []extensions.IngressTLS{
-			{
-				Hosts:      []string{cc.Ingress.Hostname},
-				SecretName: cc.Ingress.TLSSecretName,
-			},
-		}
+	hosts := []interface{}{}
+	for _, rule := range GetIngressRules(cc) {
+		hosts = append(hosts, rule.Host)
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"hosts":      hosts,
+			"secretName": cc.Ingress.TLSSecretName,
+		},
 	}
-	return nil
 }
 
-func getIngressSpec(cc *binaryomenv1alpha1.NodeSpec) extensions.IngressSpec {
-	return extensions.IngressSpec{
-		TLS: getIngressTLS(cc),
-		Rules: []extensions.IngressRule{
-			{
-				Host: GetHost(cc),
-				IngressRuleValue: extensions.IngressRuleValue{
-					HTTP: &extensions.HTTPIngressRuleValue{
-						Paths: []extensions.HTTPIngressPath{
-							{
-								Path: GetPath(cc),
-								Backend: extensions.IngressBackend{
-									ServiceName: cc.Name,
-									ServicePort: intstr.FromInt(int(cc.Service.Port)),
-								},
-							},
-						},
-					},
-				},
+func getIngressSpec(cc *binaryomenv1alpha1.NodeSpec, apiVersion string) map[string]interface{} {
+	rules := []interface{}{}
+	for _, rule := range GetIngressRules(cc) {
+		paths := []interface{}{}
+		for _, path := range rule.Paths {
+			p := map[string]interface{}{
+				"path":    path.Path,
+				"backend": getIngressBackend(cc, path, apiVersion),
+			}
+			// older api servers drop pathType, which would be reported as drift
+			if apiVersion == capabilities.IngressV1 {
+				p["pathType"] = getPathType(cc, path)
+			}
+			paths = append(paths, p)
+		}
+		rules = append(rules, map[string]interface{}{
+			"host": rule.Host,
+			"http": map[string]interface{}{
+				"paths": paths,
 			},
+		})
+	}
+
+	spec := map[string]interface{}{
+		"rules": rules,
+	}
+	if tls := getIngressTLS(cc); tls != nil {
+		spec["tls"] = tls
+	}
+	if cc.Ingress.IngressClassName != "" && apiVersion == capabilities.IngressV1 {
+		spec["ingressClassName"] = cc.Ingress.IngressClassName
+	}
+	return spec
+}
+
+// getIngressBackend is a service name and port on networking.k8s.io/v1, a serviceName and servicePort before
+func getIngressBackend(cc *binaryomenv1alpha1.NodeSpec, path binaryomenv1alpha1.DruidIngressPath, apiVersion string) map[string]interface{} {
+	port := getIngressServicePort(cc, path)
+	if apiVersion != capabilities.IngressV1 {
+		var servicePort interface{} = port.StrVal
+		if port.Type == intstr.Int {
+			servicePort = int64(port.IntVal)
+		}
+		return map[string]interface{}{
+			"serviceName": cc.Name,
+			"servicePort": servicePort,
+		}
+	}
+
+	backendPort := map[string]interface{}{"name": port.StrVal}
+	if port.Type == intstr.Int {
+		backendPort = map[string]interface{}{"number": int64(port.IntVal)}
+	}
+	return map[string]interface{}{
+		"service": map[string]interface{}{
+			"name": cc.Name,
+			"port": backendPort,
 		},
 	}
 }
 
+func getPathType(cc *binaryomenv1alpha1.NodeSpec, path binaryomenv1alpha1.DruidIngressPath) string {
+	if path.PathType != "" {
+		return path.PathType
+	}
+	if cc.Ingress.PathType != "" {
+		return cc.Ingress.PathType
+	}
+	return defaultPathType
+}
+
 func GetHost(cc *binaryomenv1alpha1.NodeSpec) string {
 	if cc.Ingress.Enabled == false {
 		return ""
@@ -71,19 +138,51 @@ func GetHost(cc *binaryomenv1alpha1.NodeSpec) string {
 }
 
 func GetPath(cc *binaryomenv1alpha1.NodeSpec) string {
-	if cc.Ingress.Enabled == false {
+	if cc.Ingress.Enabled == false || cc.Ingress.Path == "" {
 		return "/"
 	}
 	return cc.Ingress.Path
 }
 
-func getIngressAnnotations(cc *binaryomenv1alpha1.NodeSpec) map[string]string {
+func getIngressLabels(cc *binaryomenv1alpha1.NodeSpec) map[string]string {
+	labels := map[string]string{}
+	for k, v := range cc.Ingress.Labels {
+		labels[k] = v
+	}
+	labels["app"] = "druid"
+	labels["type"] = cc.NodeType
+	labels["name"] = cc.Name
+	return labels
+}
+
+// getIngressAnnotations sets the ingress class annotation read by controllers predating ingressClassName
+func getIngressAnnotations(cc *binaryomenv1alpha1.NodeSpec, apiVersion string) map[string]string {
 	annotations := make(map[string]string)
 
 	if cc.Ingress.Annotations == nil {
 		annotations["app"] = cc.Name
-		return annotations
 	} else {
-		return cc.Ingress.Annotations
+		for k, v := range cc.Ingress.Annotations {
+			annotations[k] = v
+		}
+	}
+	if _, ok := annotations[ingressClassAnnotation]; !ok && cc.Ingress.IngressClassName != "" && apiVersion != capabilities.IngressV1 {
+		annotations[ingressClassAnnotation] = cc.Ingress.IngressClassName
+	}
+	return annotations
+}
+
+// getIngressServicePort routes to the path TargetPort by name, then the Ingress TargetPort, or to the http port of the node
+func getIngressServicePort(cc *binaryomenv1alpha1.NodeSpec, path binaryomenv1alpha1.DruidIngressPath) intstr.IntOrString {
+	if path.TargetPort != "" {
+		return intstr.FromString(path.TargetPort)
+	}
+	if cc.Ingress.TargetPort != "" {
+		return intstr.FromString(cc.Ingress.TargetPort)
+	}
+	if len(cc.Service.Ports) == 0 {
+		return intstr.FromInt(int(cc.Service.Port))
 	}
+	port, _ := getHTTPPort(cc)
+	return intstr.FromString(port.Name)
 }
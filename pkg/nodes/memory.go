@@ -0,0 +1,188 @@
+package nodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	mebibyte          int64 = 1024 * 1024
+	maxBufferSize     int64 = 1024 * mebibyte
+	maxBufferSizeJava int64 = 2147483647
+)
+
+// memoryShare is the part of the container memory given to the heap and to the processing buffers,
+// the rest is left to the page cache, peons or jvm overhead
+type memoryShare struct {
+	heap       float64
+	direct     float64
+	processing bool
+}
+
+var memoryShares = map[string]memoryShare{
+	"historical":    {heap: 0.25, direct: 0.5, processing: true},
+	"broker":        {heap: 0.5, direct: 0.4, processing: true},
+	"indexer":       {heap: 0.4, direct: 0.4, processing: true},
+	"coordinator":   {heap: 0.75},
+	"overlord":      {heap: 0.75},
+	"router":        {heap: 0.75},
+	"middleManager": {heap: 0.1},
+}
+
+// MemorySettings are the jvm and processing settings computed by the memory model
+type MemorySettings struct {
+	Limit           int64
+	Heap            int64
+	DirectMemory    int64
+	NumThreads      int64
+	NumMergeBuffers int64
+	BufferSize      int64
+	Processing      bool
+}
+
+// ComputeMemorySettings applies the memory model of a node to its container memory and cpu,
+// processing settings from RuntimeProperties take precedence over the model
+func ComputeMemorySettings(cc *binaryomenv1alpha1.NodeSpec) (*MemorySettings, error) {
+	model := cc.MemoryModel
+	share, ok := memoryShares[cc.NodeType]
+	if !ok {
+		return nil, fmt.Errorf("no memory model for NodeType [%s]", cc.NodeType)
+	}
+
+	limit := getResource(cc.Resources, v1.ResourceMemory).Value()
+	if limit <= 0 {
+		return nil, fmt.Errorf("memory limit or request missing for memory model")
+	}
+
+	s := &MemorySettings{Limit: limit, Processing: share.processing}
+
+	s.Heap = int64(float64(limit)*share.heap) / mebibyte * mebibyte
+	if model.Heap != nil {
+		s.Heap = model.Heap.Value()
+	}
+
+	if !s.Processing {
+		return s, nil
+	}
+
+	var err error
+	cpus := getResource(cc.Resources, v1.ResourceCPU).MilliValue()
+	s.NumThreads = (cpus+999)/1000 - 1
+	if s.NumThreads < 1 {
+		s.NumThreads = 1
+	}
+	if model.NumThreads > 0 {
+		s.NumThreads = int64(model.NumThreads)
+	}
+	if s.NumThreads, err = getIntProperty(cc, "druid.processing.numThreads", s.NumThreads, parseInt); err != nil {
+		return nil, err
+	}
+
+	s.NumMergeBuffers = s.NumThreads / 4
+	if s.NumMergeBuffers < 2 {
+		s.NumMergeBuffers = 2
+	}
+	if model.NumMergeBuffers > 0 {
+		s.NumMergeBuffers = int64(model.NumMergeBuffers)
+	}
+	if s.NumMergeBuffers, err = getIntProperty(cc, "druid.processing.numMergeBuffers", s.NumMergeBuffers, parseInt); err != nil {
+		return nil, err
+	}
+
+	buffers := s.NumThreads + s.NumMergeBuffers + 1
+	s.BufferSize = int64(float64(limit)*share.direct) / buffers / mebibyte * mebibyte
+	if s.BufferSize > maxBufferSize {
+		s.BufferSize = maxBufferSize
+	}
+	if model.BufferSize != nil {
+		s.BufferSize = model.BufferSize.Value()
+	}
+	if s.BufferSize, err = getIntProperty(cc, "druid.processing.buffer.sizeBytes", s.BufferSize, ParseHumanReadableBytes); err != nil {
+		return nil, err
+	}
+	if s.BufferSize <= 0 {
+		return nil, fmt.Errorf("memory limit [%d] too small for %d processing buffers", limit, buffers)
+	}
+	if s.BufferSize > maxBufferSizeJava {
+		return nil, fmt.Errorf("processing buffer size [%d] exceeds %d", s.BufferSize, maxBufferSizeJava)
+	}
+
+	s.DirectMemory = (buffers*s.BufferSize + mebibyte - 1) / mebibyte * mebibyte
+	return s, nil
+}
+
+// getResource prefers the container limit and falls back to the request
+func getResource(resources v1.ResourceRequirements, name v1.ResourceName) *resource.Quantity {
+	if q, ok := resources.Limits[name]; ok {
+		return &q
+	}
+	if q, ok := resources.Requests[name]; ok {
+		return &q
+	}
+	return &resource.Quantity{}
+}
+
+// getIntProperty parses key from RuntimeProperties, a value druid would not parse either is an error
+func getIntProperty(cc *binaryomenv1alpha1.NodeSpec, key string, defaultValue int64, parse func(string) (int64, error)) (int64, error) {
+	value, ok := GetNodeProperty(cc, key)
+	if !ok {
+		return defaultValue, nil
+	}
+	i, err := parse(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s [%s]", key, value)
+	}
+	return i, nil
+}
+
+func parseInt(value string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+}
+
+func getMemoryProperties(cc *binaryomenv1alpha1.NodeSpec) []property {
+	if cc.MemoryModel == nil {
+		return nil
+	}
+	s, err := ComputeMemorySettings(cc)
+	if err != nil || !s.Processing {
+		return nil
+	}
+	return []property{
+		{key: "druid.processing.numThreads", value: fmt.Sprintf("%d", s.NumThreads)},
+		{key: "druid.processing.numMergeBuffers", value: fmt.Sprintf("%d", s.NumMergeBuffers)},
+		{key: "druid.processing.buffer.sizeBytes", value: fmt.Sprintf("%d", s.BufferSize)},
+	}
+}
+
+// applyMemoryModel replaces the heap and direct memory flags of the jvm options with the computed ones
+func applyMemoryModel(cc *binaryomenv1alpha1.NodeSpec, jvmOptions string) string {
+	if cc.MemoryModel == nil {
+		return jvmOptions
+	}
+	s, err := ComputeMemorySettings(cc)
+	if err != nil {
+		return jvmOptions
+	}
+
+	options := []string{}
+	for _, line := range strings.Split(jvmOptions, "\n") {
+		option := strings.TrimSpace(line)
+		if option == "" || strings.HasPrefix(option, "-Xms") || strings.HasPrefix(option, "-Xmx") || strings.HasPrefix(option, "-XX:MaxDirectMemorySize") {
+			continue
+		}
+		options = append(options, line)
+	}
+	options = append(options,
+		fmt.Sprintf("-Xms%dm", s.Heap/mebibyte),
+		fmt.Sprintf("-Xmx%dm", s.Heap/mebibyte),
+	)
+	if s.Processing {
+		options = append(options, fmt.Sprintf("-XX:MaxDirectMemorySize=%dm", s.DirectMemory/mebibyte))
+	}
+	return strings.Join(options, "\n")
+}
@@ -0,0 +1,171 @@
+package nodes
+
+import (
+	"fmt"
+	"sort"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	prometheusEmitterExtension = "prometheus-emitter"
+	defaultMetricsPort         = 9000
+	defaultMetricsNamespace    = "druid"
+)
+
+// IsMonitoringEnabled tells whether the nodes emit prometheus metrics
+func IsMonitoringEnabled(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.Monitoring != nil && c.Spec.Monitoring.Enabled
+}
+
+// GetMonitorKind returns the kind of the prometheus-operator monitor of the cluster
+func GetMonitorKind(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Monitoring.Kind == "" {
+		return binaryomenv1alpha1.MonitorService
+	}
+	return c.Spec.Monitoring.Kind
+}
+
+// GetMetricsPortNumber returns the port the prometheus emitter serves metrics on
+func GetMetricsPortNumber(c *binaryomenv1alpha1.Druid) int32 {
+	if c.Spec.Monitoring.Port == 0 {
+		return defaultMetricsPort
+	}
+	return c.Spec.Monitoring.Port
+}
+
+// getMetricsPort returns the port the emitter serves metrics on, unless the node already names a metrics port,
+// validated to target the same port
+func getMetricsPort(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) (int32, bool) {
+	if !IsMonitoringEnabled(c) {
+		return 0, false
+	}
+	if _, ok := GetServicePort(cc, binaryomenv1alpha1.PortMetrics); ok {
+		return 0, false
+	}
+	return GetMetricsPortNumber(c), true
+}
+
+func makeMetricsServicePort(port int32) v1.ServicePort {
+	return v1.ServicePort{
+		Name:       binaryomenv1alpha1.PortMetrics,
+		Port:       port,
+		TargetPort: intstr.FromInt(int(port)),
+		Protocol:   v1.ProtocolTCP,
+	}
+}
+
+// getMonitoringProperties configures the prometheus emitter to serve the metrics of every node on the metrics port
+func getMonitoringProperties(c *binaryomenv1alpha1.Druid) []property {
+	if !IsMonitoringEnabled(c) {
+		return nil
+	}
+	namespace := c.Spec.Monitoring.Namespace
+	if namespace == "" {
+		namespace = defaultMetricsNamespace
+	}
+	return []property{
+		{key: "druid.emitter", value: "prometheus"},
+		{key: "druid.emitter.prometheus.strategy", value: "exporter"},
+		{key: "druid.emitter.prometheus.port", value: fmt.Sprintf("%d", GetMetricsPortNumber(c))},
+		{key: "druid.emitter.prometheus.namespace", value: namespace},
+		{key: "druid.emitter.prometheus.addServiceAsLabel", value: "true"},
+	}
+}
+
+// getPeonMonitoringProperties turns the emitter off in the peons forked by middleManagers, they run in the
+// middleManager pod and would bind its metrics port
+func getPeonMonitoringProperties(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []property {
+	if !IsMonitoringEnabled(c) || cc.NodeType != "middleManager" {
+		return nil
+	}
+	return []property{
+		{key: "druid.indexer.fork.property.druid.emitter", value: "noop"},
+	}
+}
+
+// pullsPrometheusEmitter tells whether the emitter is among the pulled extensions, it is then loaded from
+// the extensions volume rather than by name
+func pullsPrometheusEmitter(c *binaryomenv1alpha1.Druid) bool {
+	if c.Spec.Extensions == nil {
+		return false
+	}
+	for _, coordinate := range c.Spec.Extensions.Coordinates {
+		if artifact, err := GetExtensionArtifact(coordinate); err == nil && artifact == prometheusEmitterExtension {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeMonitor renders the ServiceMonitor or PodMonitor scraping the metrics port of the nodes of the cluster,
+// as an unstructured object since the prometheus-operator CRDs may not be installed. The monitor selects
+// the nodes by name, so that the clusters of a namespace are scraped by their own monitor
+func MakeMonitor(c *binaryomenv1alpha1.Druid, apiVersion string) *unstructured.Unstructured {
+	m := c.Spec.Monitoring
+	kind := GetMonitorKind(c)
+
+	endpoint := map[string]interface{}{
+		"port": binaryomenv1alpha1.PortMetrics,
+		"path": "/metrics",
+	}
+	if m.Interval != "" {
+		endpoint["interval"] = m.Interval
+	}
+	endpointsKey := "endpoints"
+	if kind == binaryomenv1alpha1.MonitorPod {
+		endpointsKey = "podMetricsEndpoints"
+	}
+
+	names := []interface{}{}
+	for _, name := range getNodeNames(c) {
+		names = append(names, name)
+	}
+
+	monitor := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"app": "druid",
+					},
+					"matchExpressions": []interface{}{
+						map[string]interface{}{
+							"key":      "name",
+							"operator": "In",
+							"values":   names,
+						},
+					},
+				},
+				"namespaceSelector": map[string]interface{}{
+					"matchNames": []interface{}{c.Namespace},
+				},
+				endpointsKey: []interface{}{endpoint},
+			},
+		},
+	}
+	monitor.SetAPIVersion(apiVersion)
+	monitor.SetKind(kind)
+	monitor.SetName(c.Name)
+	monitor.SetNamespace(c.Namespace)
+
+	labels := map[string]string{}
+	for k, v := range m.Labels {
+		labels[k] = v
+	}
+	labels["app"] = "druid"
+	monitor.SetLabels(labels)
+	return monitor
+}
+
+func getNodeNames(c *binaryomenv1alpha1.Druid) []string {
+	names := []string{}
+	for _, n := range c.Spec.Nodes {
+		names = append(names, n.Name)
+	}
+	sort.Strings(names)
+	return names
+}
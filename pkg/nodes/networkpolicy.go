@@ -0,0 +1,114 @@
+package nodes
+
+import (
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// operatorName labels the operator pods, it calls the overlord when tearing down or hibernating a cluster
+const operatorName = "druid-operator"
+
+// nodeClients lists the node types sending requests to each node type. Brokers query historicals and the
+// peons of middleManagers and indexers, the overlord assigns tasks, peons of parallel tasks talk to each
+// other and report to the overlord and coordinator, the router proxies to brokers, coordinators and overlords.
+// The coordinator pushes lookups to brokers, historicals and peons, brokers query the overlord for the
+// sys.tasks and sys.supervisors tables
+var nodeClients = map[string][]string{
+	"historical":    {"broker", "coordinator"},
+	"middleManager": {"broker", "coordinator", "overlord", "middleManager", "indexer"},
+	"indexer":       {"broker", "coordinator", "overlord", "middleManager", "indexer"},
+	"broker":        {"router", "coordinator"},
+	"coordinator":   {"router", "broker", "coordinator", "overlord", "historical", "middleManager", "indexer"},
+	"overlord":      {"router", "broker", "coordinator", "overlord", "middleManager", "indexer"},
+	"router":        {},
+}
+
+// IsNetworkPolicyEnabled reports whether the operator restricts the traffic between the nodes
+func IsNetworkPolicyEnabled(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.NetworkPolicy != nil && c.Spec.NetworkPolicy.Enabled
+}
+
+// MakeNetworkPolicy allows the ingress of a node from the node types sending it requests and the user sources,
+// and of its metrics port from the monitoring sources
+func MakeNetworkPolicy(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *networkingv1.NetworkPolicy {
+	peers := []networkingv1.NetworkPolicyPeer{}
+	for _, nodeType := range nodeClients[cc.NodeType] {
+		peers = append(peers, makeNodeTypePeer(nodeType))
+	}
+	if cc.NodeType == "overlord" || cc.NodeType == "coordinator" {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"name": operatorName}},
+		})
+	}
+	peers = append(peers, c.Spec.NetworkPolicy.Ingress...)
+	peers = append(peers, cc.NetworkPolicyIngress...)
+
+	np := makeNetworkPolicy(makeNetworkPolicyName(cc.Name), c, map[string]string{
+		"app":  "druid",
+		"type": cc.NodeType,
+		"name": cc.Name,
+	}, peers)
+	if IsMonitoringEnabled(c) && len(c.Spec.NetworkPolicy.Monitoring) > 0 {
+		port := intstr.FromInt(int(GetMetricsPortNumber(c)))
+		protocol := v1.ProtocolTCP
+		np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}},
+			From:  c.Spec.NetworkPolicy.Monitoring,
+		})
+	}
+	return np
+}
+
+// MakeZookeeperNetworkPolicy allows the ingress of the managed zookeeper from the druid nodes and its peers
+func MakeZookeeperNetworkPolicy(c *binaryomenv1alpha1.Druid) *networkingv1.NetworkPolicy {
+	peers := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "druid"}}},
+		{PodSelector: &metav1.LabelSelector{MatchLabels: makeZookeeperLabels(c)}},
+	}
+	peers = append(peers, c.Spec.NetworkPolicy.Ingress...)
+
+	return makeNetworkPolicy(makeNetworkPolicyName(makeZookeeperName(c)), c, makeZookeeperLabels(c), peers)
+}
+
+func makeNetworkPolicy(name string, c *binaryomenv1alpha1.Druid, selector map[string]string, peers []networkingv1.NetworkPolicyPeer) *networkingv1.NetworkPolicy {
+	rules := []networkingv1.NetworkPolicyIngressRule{}
+	if len(peers) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{From: peers})
+	}
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels:    selector,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: selector},
+			Ingress:     rules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+func makeNodeTypePeer(nodeType string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"app":  "druid",
+				"type": nodeType,
+			},
+		},
+	}
+}
+
+func makeNetworkPolicyName(name string) string {
+	return fmt.Sprintf("%s-ingress", name)
+}
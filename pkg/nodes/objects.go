@@ -10,6 +10,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	podIPEnv        = "POD_IP"
+	javaOptsEnv     = "JAVA_OPTS"
+	userJavaOptsEnv = "DRUID_USER_JAVA_OPTS"
+)
+
 func MakeStatefulSet(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *appsv1.StatefulSet {
 
 	return &appsv1.StatefulSet{
@@ -42,7 +48,7 @@ func MakeDeployment(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid
 func makeStatefulSetSpec(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) appsv1.StatefulSetSpec {
 
 	s := appsv1.StatefulSetSpec{
-		ServiceName: cc.Name,
+		ServiceName: makeHeadlessServiceName(cc),
 		Selector: &metav1.LabelSelector{
 			MatchLabels: map[string]string{
 				"app":  "druid",
@@ -109,12 +115,15 @@ func makePodTemplate(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Drui
 func makePodSpec(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) v1.PodSpec {
 
 	spec := v1.PodSpec{
-		NodeSelector:     cc.NodeSelector,
-		Tolerations:      getTolerations(cc, c),
-		Affinity:         getAffinity(cc, c),
-		Volumes:          getVolumes(cc, cc.Volumes),
-		ImagePullSecrets: c.Spec.ImagePullSecrets,
-		SecurityContext:  cc.SecurityContext,
+		NodeSelector:              cc.NodeSelector,
+		Tolerations:               getTolerations(cc, c),
+		Affinity:                  getAffinity(cc, c),
+		TopologySpreadConstraints: getTopologySpreadConstraints(cc),
+		Volumes:                   getVolumes(cc, c, cc.Volumes),
+		ImagePullSecrets:          c.Spec.ImagePullSecrets,
+		SecurityContext:           cc.SecurityContext,
+		ServiceAccountName:        getServiceAccountName(c),
+		InitContainers:            getInitContainers(cc, c),
 		Containers: []v1.Container{
 			{
 				Name:                     cc.Name,
@@ -124,14 +133,8 @@ func makePodSpec(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) v
 				Env:                      getEnv(cc, c),
 				TerminationMessagePath:   "/dev/termination-log",
 				TerminationMessagePolicy: "File",
-				Ports: []v1.ContainerPort{
-					{
-						Name:          cc.Name,
-						ContainerPort: cc.Service.TargetPort,
-						Protocol:      v1.Protocol("TCP"),
-					},
-				},
-				VolumeMounts: getVolumeMounts(cc, c, cc.VolumeMounts),
+				Ports:                    getContainerPorts(cc, c),
+				VolumeMounts:             getVolumeMounts(cc, c, cc.VolumeMounts),
 			},
 		},
 	}
@@ -168,13 +171,25 @@ func getVolumeMounts(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Drui
 			MountPath: c.Spec.CommonConfigMountPath,
 		},
 	}
+	if pullExtensions(cc, c) {
+		volumeMount = append(volumeMount, makeExtensionsVolumeMount(c))
+	}
+	if c.Spec.TLS != nil {
+		volumeMount = append(volumeMount, makeTLSVolumeMount())
+	}
 	for _, val := range vmM {
 		volumeMount = append(volumeMount, val)
 	}
+	if cc.SegmentCache != nil && GetVolumeMount(cc, cc.SegmentCache.VolumeClaimTemplate) == nil {
+		volumeMount = append(volumeMount, v1.VolumeMount{
+			Name:      cc.SegmentCache.VolumeClaimTemplate,
+			MountPath: cc.SegmentCache.MountPath,
+		})
+	}
 	return volumeMount
 }
 
-func getVolumes(cc *binaryomenv1alpha1.NodeSpec, vm []v1.Volume) []v1.Volume {
+func getVolumes(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, vm []v1.Volume) []v1.Volume {
 	volumes := []v1.Volume{
 		{
 			Name: makeConfigMapName(cc),
@@ -198,12 +213,29 @@ func getVolumes(cc *binaryomenv1alpha1.NodeSpec, vm []v1.Volume) []v1.Volume {
 		},
 	}
 
+	if pullExtensions(cc, c) {
+		volumes = append(volumes, makeExtensionsVolume(c))
+	}
+	if c.Spec.TLS != nil {
+		volumes = append(volumes, makeTLSVolumes(cc, c)...)
+	}
 	for _, val := range vm {
 		volumes = append(volumes, val)
 	}
 	return volumes
 }
 
+func getInitContainers(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.Container {
+	initContainers := []v1.Container{}
+	if pullExtensions(cc, c) {
+		initContainers = append(initContainers, makePullDepsContainer(cc, c))
+	}
+	if c.Spec.TLS != nil {
+		initContainers = append(initContainers, makeKeystoreContainer(cc, c))
+	}
+	return initContainers
+}
+
 func getVolumeClaimTemplates(vcT []v1.PersistentVolumeClaim) []v1.PersistentVolumeClaim {
 	pvc := []v1.PersistentVolumeClaim{}
 
@@ -236,11 +268,95 @@ func getAffinity(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *
 
 func getEnv(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.EnvVar {
 	env := []v1.EnvVar{}
+	for _, val := range getHostEnv(cc, c) {
+		env = append(env, val)
+	}
+	for _, val := range getDiscoveryEnv(c) {
+		env = append(env, val)
+	}
+	for _, val := range getKeystorePasswordEnv(c) {
+		env = append(env, val)
+	}
+	for _, val := range getAuthenticationEnv(c) {
+		env = append(env, val)
+	}
 	for _, val := range c.Spec.Env {
 		env = append(env, val)
 	}
 	for _, val := range cc.Env {
 		env = append(env, val)
 	}
-	return env
+	return appendHostJavaOpts(cc, c, env)
+}
+
+// getHostEnv exposes the pod ip or name druid.host is set to, unless the user provides druid.host
+func getHostEnv(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.EnvVar {
+	if !setsHost(cc, c) {
+		return nil
+	}
+	if announcesDNSName(c) {
+		// the kubernetes discovery already exposes the pod name
+		if !usesStatefulSet(cc) || c.Spec.Discovery == binaryomenv1alpha1.DiscoveryKubernetes {
+			return nil
+		}
+		return []v1.EnvVar{makeFieldEnv(podNameEnv, "metadata.name")}
+	}
+	return []v1.EnvVar{makeFieldEnv(podIPEnv, "status.podIP")}
+}
+
+// getHost returns the host a node announces, its pod ip, or with the certificates behind TLS SecretName the
+// dns name of its pod for statefulsets and of its service otherwise
+func getHost(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) string {
+	if !announcesDNSName(c) {
+		return fmt.Sprintf("$(%s)", podIPEnv)
+	}
+	if usesStatefulSet(cc) {
+		return fmt.Sprintf("$(%s).%s.%s.svc", podNameEnv, makeHeadlessServiceName(cc), c.Namespace)
+	}
+	return fmt.Sprintf("%s.%s.svc", cc.Name, c.Namespace)
+}
+
+// appendHostJavaOpts sets druid.host through JAVA_OPTS, system properties take precedence over runtime.properties
+// and the start script of the druid images passes JAVA_OPTS to java. The user JAVA_OPTS are renamed and
+// expanded ahead of the druid.host flag, so that they are kept
+func appendHostJavaOpts(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, env []v1.EnvVar) []v1.EnvVar {
+	if !setsHost(cc, c) {
+		return env
+	}
+	javaOpts := fmt.Sprintf("-Ddruid.host=%s", getHost(cc, c))
+	withJavaOpts := []v1.EnvVar{}
+	for _, val := range env {
+		if val.Name == javaOptsEnv {
+			val.Name = userJavaOptsEnv
+			javaOpts = fmt.Sprintf("$(%s) -Ddruid.host=%s", userJavaOptsEnv, getHost(cc, c))
+		}
+		withJavaOpts = append(withJavaOpts, val)
+	}
+	return append(withJavaOpts, v1.EnvVar{Name: javaOptsEnv, Value: javaOpts})
+}
+
+// AnnouncesServiceName tells whether the pods of a node announce the dns name of its service, druid then
+// tells its replicas apart from their port only
+func AnnouncesServiceName(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) bool {
+	return setsHost(cc, c) && announcesDNSName(c) && !usesStatefulSet(cc)
+}
+
+// setsHost tells whether the operator sets druid.host, the user value wins
+func setsHost(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) bool {
+	_, ok := GetNodeProperty(cc, "druid.host")
+	return !ok && !HasCommonProperty(c, "druid.host")
+}
+
+// usesStatefulSet tells whether a node runs as a statefulset, its pods then have a stable dns name
+func usesStatefulSet(cc *binaryomenv1alpha1.NodeSpec) bool {
+	return cc.NodeType == "historical" || cc.NodeType == "middleManager"
+}
+
+func makeFieldEnv(name string, fieldPath string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			FieldRef: &v1.ObjectFieldSelector{FieldPath: fieldPath},
+		},
+	}
 }
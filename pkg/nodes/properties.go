@@ -0,0 +1,110 @@
+package nodes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+)
+
+// property is a single key/value pair generated into a runtime.properties file
+type property struct {
+	key   string
+	value string
+}
+
+// parseProperty splits a runtime.properties line into key and value, comments and blank lines are skipped
+func parseProperty(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+		return "", "", false
+	}
+	i := strings.IndexAny(line, "=: \t")
+	if i < 0 {
+		return line, "", true
+	}
+	key := line[:i]
+	value := strings.TrimLeft(line[i:], " \t")
+	if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+		value = strings.TrimLeft(value[1:], " \t")
+	}
+	return key, value, true
+}
+
+// GetCommonProperty returns the value of key in CommonRuntimeProperties
+func GetCommonProperty(c *binaryomenv1alpha1.Druid, key string) (string, bool) {
+	return getProperty(c.Spec.CommonRuntimeProperties, key)
+}
+
+// HasCommonProperty reports whether key is set in CommonRuntimeProperties
+func HasCommonProperty(c *binaryomenv1alpha1.Druid, key string) bool {
+	_, ok := getProperty(c.Spec.CommonRuntimeProperties, key)
+	return ok
+}
+
+// byteUnits are the decimal and binary units of druid human-readable byte sizes, eg 500m or 500MiB
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40}, {"pib", 1 << 50},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9}, {"t", 1e12}, {"p", 1e15},
+}
+
+// ParseHumanReadableBytes parses a byte size the way druid does, a number of bytes optionally followed by
+// a case insensitive decimal or binary unit
+func ParseHumanReadableBytes(value string) (int64, error) {
+	number, multiplier := strings.TrimSpace(value), int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(strings.ToLower(number), unit.suffix) {
+			number, multiplier = number[:len(number)-len(unit.suffix)], unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid byte size [%s]", value)
+	}
+	return n * multiplier, nil
+}
+
+// getProperty returns the last value set for key in props
+func getProperty(props string, key string) (string, bool) {
+	value, found := "", false
+	for _, line := range strings.Split(props, "\n") {
+		if k, v, ok := parseProperty(line); ok && k == key {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+// removeProperty drops every line setting key from props
+func removeProperty(props string, key string) string {
+	lines := []string{}
+	for _, line := range strings.Split(props, "\n") {
+		if k, _, ok := parseProperty(line); ok && k == key {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendProperties appends the generated properties which are not already set in props, user values always win
+func appendProperties(props string, generated []property) string {
+	var b strings.Builder
+	b.WriteString(props)
+	for _, p := range generated {
+		if _, ok := getProperty(props, p.key); ok {
+			continue
+		}
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%s=%s\n", p.key, p.value))
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package nodes
+
+import (
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MakeRoute renders the OpenShift Route of a node, as an unstructured object since the Route api is only
+// served by OpenShift
+func MakeRoute(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid, apiVersion string) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": getRouteSpec(cc),
+		},
+	}
+	route.SetAPIVersion(apiVersion)
+	route.SetKind("Route")
+	route.SetName(cc.Name)
+	route.SetNamespace(c.Namespace)
+	route.SetLabels(map[string]string{
+		"app":  "druid",
+		"type": cc.NodeType,
+		"name": cc.Name,
+	})
+	if len(cc.Route.Annotations) > 0 {
+		route.SetAnnotations(cc.Route.Annotations)
+	}
+	return route
+}
+
+func getRouteSpec(cc *binaryomenv1alpha1.NodeSpec) map[string]interface{} {
+	r := cc.Route
+
+	spec := map[string]interface{}{
+		"to": map[string]interface{}{
+			"kind":   "Service",
+			"name":   cc.Name,
+			"weight": int64(100),
+		},
+		"port": map[string]interface{}{
+			"targetPort": getRouteTargetPort(cc),
+		},
+		"wildcardPolicy": "None",
+	}
+	if r.Host != "" {
+		spec["host"] = r.Host
+	}
+	if r.Path != "" {
+		spec["path"] = r.Path
+	}
+	if r.TLS != nil {
+		tls := map[string]interface{}{
+			"termination": r.TLS.Termination,
+		}
+		if r.TLS.InsecureEdgeTerminationPolicy != "" {
+			tls["insecureEdgeTerminationPolicy"] = r.TLS.InsecureEdgeTerminationPolicy
+		}
+		if r.TLS.DestinationCACertificate != "" {
+			tls["destinationCACertificate"] = r.TLS.DestinationCACertificate
+		}
+		spec["tls"] = tls
+	}
+	return spec
+}
+
+// getRouteTargetPort names the Service port, the single port of nodes without named ports is unnamed
+// and referenced by its target port
+func getRouteTargetPort(cc *binaryomenv1alpha1.NodeSpec) interface{} {
+	if cc.Route.TargetPort != "" {
+		return cc.Route.TargetPort
+	}
+	if len(cc.Service.Ports) == 0 {
+		return int64(cc.Service.TargetPort)
+	}
+	port, _ := getHTTPPort(cc)
+	return port.Name
+}
@@ -0,0 +1,113 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const defaultSegmentCacheHeadroomPercent int32 = 10
+
+// segmentCacheLocation is an entry of druid.segmentCache.locations
+type segmentCacheLocation struct {
+	Path    string             `json:"path"`
+	MaxSize humanReadableBytes `json:"maxSize"`
+}
+
+// humanReadableBytes reads a byte size given as a number or as a human-readable string, eg "300g"
+type humanReadableBytes int64
+
+func (b *humanReadableBytes) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		value = string(data)
+	}
+	size, err := ParseHumanReadableBytes(value)
+	if err != nil {
+		return err
+	}
+	*b = humanReadableBytes(size)
+	return nil
+}
+
+// GetVolumeClaimTemplate returns the volume claim template of the node with the given name
+func GetVolumeClaimTemplate(cc *binaryomenv1alpha1.NodeSpec, name string) *v1.PersistentVolumeClaim {
+	for i := range cc.VolumeClaimTemplates {
+		if cc.VolumeClaimTemplates[i].Name == name {
+			return &cc.VolumeClaimTemplates[i]
+		}
+	}
+	return nil
+}
+
+// GetVolumeMount returns the user volume mount of the volume with the given name
+func GetVolumeMount(cc *binaryomenv1alpha1.NodeSpec, name string) *v1.VolumeMount {
+	for i := range cc.VolumeMounts {
+		if cc.VolumeMounts[i].Name == name {
+			return &cc.VolumeMounts[i]
+		}
+	}
+	return nil
+}
+
+// ComputeSegmentCacheSize returns the storage requested by the segment cache claim minus the headroom
+func ComputeSegmentCacheSize(cc *binaryomenv1alpha1.NodeSpec) (int64, error) {
+	pvc := GetVolumeClaimTemplate(cc, cc.SegmentCache.VolumeClaimTemplate)
+	if pvc == nil {
+		return 0, fmt.Errorf("volume claim template [%s] not found for segment cache", cc.SegmentCache.VolumeClaimTemplate)
+	}
+	storage, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if !ok {
+		return 0, fmt.Errorf("volume claim template [%s] has no storage request for segment cache", pvc.Name)
+	}
+
+	headroom := defaultSegmentCacheHeadroomPercent
+	if cc.SegmentCache.HeadroomPercent != nil {
+		headroom = *cc.SegmentCache.HeadroomPercent
+	}
+	if headroom < 0 || headroom > 99 {
+		return 0, fmt.Errorf("segment cache headroom [%d] must be between 0 and 99 percent", headroom)
+	}
+
+	size := storage.Value() / 100 * int64(100-headroom)
+	if size <= 0 {
+		return 0, fmt.Errorf("volume claim template [%s] too small for segment cache", pvc.Name)
+	}
+	return size, nil
+}
+
+// GetUserSegmentCacheSize sums the maxSize of druid.segmentCache.locations set in RuntimeProperties
+func GetUserSegmentCacheSize(cc *binaryomenv1alpha1.NodeSpec) (int64, bool, error) {
+	value, ok := GetNodeProperty(cc, "druid.segmentCache.locations")
+	if !ok {
+		return 0, false, nil
+	}
+	locations := []segmentCacheLocation{}
+	if err := json.Unmarshal([]byte(value), &locations); err != nil {
+		return 0, true, fmt.Errorf("invalid druid.segmentCache.locations [%s]: %v", value, err)
+	}
+	var size int64
+	for _, l := range locations {
+		size += int64(l.MaxSize)
+	}
+	return size, true, nil
+}
+
+func getSegmentCacheProperties(cc *binaryomenv1alpha1.NodeSpec) []property {
+	if cc.SegmentCache == nil {
+		return nil
+	}
+	size, err := ComputeSegmentCacheSize(cc)
+	if err != nil {
+		return nil
+	}
+	locations, _ := json.Marshal([]segmentCacheLocation{
+		{Path: cc.SegmentCache.MountPath, MaxSize: humanReadableBytes(size)},
+	})
+	return []property{
+		{key: "druid.segmentCache.locations", value: string(locations)},
+		{key: "druid.server.maxSize", value: fmt.Sprintf("%d", size)},
+	}
+}
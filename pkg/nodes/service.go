@@ -1,47 +1,267 @@
 package nodes
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// AppliedLabelsAnnotation and AppliedAnnotationsAnnotation list the label and annotation keys of a rendered
+	// service, keys rendered before and no longer rendered are removed from the live service
+	AppliedLabelsAnnotation      = "druid.binaryomen.org/applied-labels"
+	AppliedAnnotationsAnnotation = "druid.binaryomen.org/applied-annotations"
+)
+
 func MakeService(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *v1.Service {
+	svc := makeService(cc, c)
+	annotateAppliedKeys(svc)
+	return svc
+}
 
+func makeService(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cc.Name,
-			Namespace: c.Namespace,
-			Labels: map[string]string{
-				"app":  "druid",
-				"type": cc.NodeType,
-				"name": cc.Name,
-			},
+			Name:        cc.Name,
+			Namespace:   c.Namespace,
+			Labels:      getServiceLabels(cc),
+			Annotations: getServiceAnnotations(cc),
 		},
 		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{
-				{
-					Port: cc.Service.Port,
-					TargetPort: intstr.IntOrString{
-						Type:   intstr.Type(0),
-						IntVal: cc.Service.TargetPort,
-					},
-					NodePort: 0,
-				},
-			},
+			Ports: getServicePorts(cc, c),
 			Selector: map[string]string{
 				"name": cc.Name,
 			},
-			ClusterIP: "",
-			Type:      getServiceType(cc),
+			ClusterIP:                "",
+			Type:                     getServiceType(cc),
+			LoadBalancerSourceRanges: cc.Service.LoadBalancerSourceRanges,
+			LoadBalancerIP:           cc.Service.LoadBalancerIP,
+			ExternalTrafficPolicy:    cc.Service.ExternalTrafficPolicy,
+			SessionAffinity:          cc.Service.SessionAffinity,
+			SessionAffinityConfig:    cc.Service.SessionAffinityConfig,
 		},
 	}
 }
 
+// MakeHeadlessService creates the service governing the statefulset of a node, giving its pods a stable dns name.
+// Not ready pods are published, the service names pods rather than balancing traffic, so it drops the
+// annotations and load balancing options of the node service. The metrics port is dropped as well, so that
+// a ServiceMonitor scrapes the pods once
+func MakeHeadlessService(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) *v1.Service {
+	svc := makeService(cc, c)
+	svc.Name = makeHeadlessServiceName(cc)
+	svc.Annotations = nil
+	ports := []v1.ServicePort{}
+	for _, port := range svc.Spec.Ports {
+		if port.Name != binaryomenv1alpha1.PortMetrics {
+			ports = append(ports, port)
+		}
+	}
+	svc.Spec = v1.ServiceSpec{
+		Ports:                    ports,
+		Selector:                 svc.Spec.Selector,
+		Type:                     v1.ServiceTypeClusterIP,
+		ClusterIP:                v1.ClusterIPNone,
+		PublishNotReadyAddresses: true,
+	}
+	annotateAppliedKeys(svc)
+	return svc
+}
+
+// annotateAppliedKeys records the label and annotation keys of a rendered service
+func annotateAppliedKeys(svc *v1.Service) {
+	annotations := svc.Annotations
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AppliedLabelsAnnotation] = joinKeys(svc.Labels)
+	annotations[AppliedAnnotationsAnnotation] = joinKeys(svc.Annotations)
+	svc.Annotations = annotations
+}
+
+// GetAppliedKeys returns the keys recorded in an applied keys annotation
+func GetAppliedKeys(annotations map[string]string, annotation string) []string {
+	if annotations[annotation] == "" {
+		return nil
+	}
+	return strings.Split(annotations[annotation], ",")
+}
+
+func joinKeys(m map[string]string) string {
+	keys := []string{}
+	for k := range m {
+		if k != AppliedLabelsAnnotation && k != AppliedAnnotationsAnnotation {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// getServiceLabels adds the user labels to the app, type and name labels, which take precedence
+func getServiceLabels(cc *binaryomenv1alpha1.NodeSpec) map[string]string {
+	labels := map[string]string{}
+	for k, v := range cc.Service.Labels {
+		labels[k] = v
+	}
+	labels["app"] = "druid"
+	labels["type"] = cc.NodeType
+	labels["name"] = cc.Name
+	return labels
+}
+
+// getServiceAnnotations copies the user annotations, the controller annotates the rendered service
+func getServiceAnnotations(cc *binaryomenv1alpha1.NodeSpec) map[string]string {
+	if cc.Service.Annotations == nil {
+		return nil
+	}
+	annotations := map[string]string{}
+	for k, v := range cc.Service.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+func makeHeadlessServiceName(cc *binaryomenv1alpha1.NodeSpec) string {
+	return fmt.Sprintf("%s-headless", cc.Name)
+}
+
 func getServiceType(cc *binaryomenv1alpha1.NodeSpec) v1.ServiceType {
 	if cc.Service.Type == "" {
 		return v1.ServiceTypeClusterIP
 	}
 	return cc.Service.Type
 }
+
+// GetServicePorts returns the named ports of a node, Port and TargetPort make the plaintext port
+// when no ports are listed
+func GetServicePorts(cc *binaryomenv1alpha1.NodeSpec) []binaryomenv1alpha1.DruidServicePort {
+	if len(cc.Service.Ports) > 0 {
+		return cc.Service.Ports
+	}
+	return []binaryomenv1alpha1.DruidServicePort{
+		{
+			Name:       binaryomenv1alpha1.PortPlaintext,
+			Port:       cc.Service.Port,
+			TargetPort: cc.Service.TargetPort,
+		},
+	}
+}
+
+// GetServicePort returns the port of a node with the given name
+func GetServicePort(cc *binaryomenv1alpha1.NodeSpec, name string) (binaryomenv1alpha1.DruidServicePort, bool) {
+	for _, port := range GetServicePorts(cc) {
+		if port.Name == name {
+			return port, true
+		}
+	}
+	return binaryomenv1alpha1.DruidServicePort{}, false
+}
+
+// getHTTPPort returns the port clients reach druid on, plaintext unless the node only serves tls
+func getHTTPPort(cc *binaryomenv1alpha1.NodeSpec) (binaryomenv1alpha1.DruidServicePort, string) {
+	if port, ok := GetServicePort(cc, binaryomenv1alpha1.PortPlaintext); ok {
+		return port, "http"
+	}
+	port, _ := GetServicePort(cc, binaryomenv1alpha1.PortTLS)
+	return port, "https"
+}
+
+// getServicePorts keeps the single unnamed port of nodes without named ports, it is named plaintext
+// when the metrics port is added since services with several ports name them all
+func getServicePorts(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.ServicePort {
+	metricsPort, addMetrics := getMetricsPort(cc, c)
+
+	if len(cc.Service.Ports) == 0 {
+		port := v1.ServicePort{
+			Port: cc.Service.Port,
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.Type(0),
+				IntVal: cc.Service.TargetPort,
+			},
+			NodePort: 0,
+		}
+		if !addMetrics {
+			return []v1.ServicePort{port}
+		}
+		port.Name = binaryomenv1alpha1.PortPlaintext
+		return []v1.ServicePort{port, makeMetricsServicePort(metricsPort)}
+	}
+
+	ports := []v1.ServicePort{}
+	for _, port := range cc.Service.Ports {
+		ports = append(ports, v1.ServicePort{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: intstr.FromInt(int(port.TargetPort)),
+			Protocol:   getProtocol(port),
+		})
+	}
+	if addMetrics {
+		ports = append(ports, makeMetricsServicePort(metricsPort))
+	}
+	return ports
+}
+
+// getContainerPorts keeps the container port named after the node for nodes without named ports
+func getContainerPorts(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.ContainerPort {
+	ports := []v1.ContainerPort{}
+	if len(cc.Service.Ports) == 0 {
+		ports = append(ports, v1.ContainerPort{
+			Name:          cc.Name,
+			ContainerPort: cc.Service.TargetPort,
+			Protocol:      v1.Protocol("TCP"),
+		})
+	}
+
+	for _, port := range cc.Service.Ports {
+		ports = append(ports, v1.ContainerPort{
+			Name:          port.Name,
+			ContainerPort: port.TargetPort,
+			Protocol:      getProtocol(port),
+		})
+	}
+	if metricsPort, ok := getMetricsPort(cc, c); ok {
+		ports = append(ports, v1.ContainerPort{
+			Name:          binaryomenv1alpha1.PortMetrics,
+			ContainerPort: metricsPort,
+			Protocol:      v1.ProtocolTCP,
+		})
+	}
+	return ports
+}
+
+func getProtocol(port binaryomenv1alpha1.DruidServicePort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// getPortProperties enables the plaintext and tls ports of a node listed in its service
+func getPortProperties(cc *binaryomenv1alpha1.NodeSpec) []property {
+	props := []property{}
+	if port, ok := GetServicePort(cc, binaryomenv1alpha1.PortPlaintext); ok {
+		props = append(props, property{key: "druid.plaintextPort", value: fmt.Sprintf("%d", port.TargetPort)})
+	} else {
+		props = append(props, property{key: "druid.enablePlaintextPort", value: "false"})
+	}
+	if port, ok := GetServicePort(cc, binaryomenv1alpha1.PortTLS); ok {
+		props = append(props,
+			property{key: "druid.enableTlsPort", value: "true"},
+			property{key: "druid.tlsPort", value: fmt.Sprintf("%d", port.TargetPort)},
+		)
+	}
+	return props
+}
+
+// GetServiceURL returns the in cluster url of the service of a node
+func GetServiceURL(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) string {
+	port, scheme := getHTTPPort(cc)
+	return fmt.Sprintf("%s://%s.%s.svc:%d", scheme, cc.Name, c.Namespace, port.Port)
+}
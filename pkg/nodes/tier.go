@@ -0,0 +1,93 @@
+package nodes
+
+import (
+	"fmt"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultTier is the tier of historicals without druid.server.tier
+const DefaultTier = "_default_tier"
+
+func getTierProperties(cc *binaryomenv1alpha1.NodeSpec) []property {
+	generated := []property{}
+	if cc.Tier != "" {
+		generated = append(generated, property{key: "druid.server.tier", value: cc.Tier})
+	}
+	if cc.TierPriority != nil {
+		generated = append(generated, property{key: "druid.server.priority", value: fmt.Sprintf("%d", *cc.TierPriority)})
+	}
+	return generated
+}
+
+// GetTier returns the tier and priority a historical node announces, properties set by the user win
+func GetTier(cc *binaryomenv1alpha1.NodeSpec) (string, int32) {
+	tier, priority := DefaultTier, int32(0)
+	if cc.Tier != "" {
+		tier = cc.Tier
+	}
+	if cc.TierPriority != nil {
+		priority = *cc.TierPriority
+	}
+	if val, ok := GetNodeProperty(cc, "druid.server.tier"); ok {
+		tier = val
+	}
+	if val, ok := GetNodeProperty(cc, "druid.server.priority"); ok {
+		var p int32
+		if _, err := fmt.Sscanf(val, "%d", &p); err == nil {
+			priority = p
+		}
+	}
+	return tier, priority
+}
+
+// getTopologySpreadConstraints spreads the pods of a node evenly across the topology key
+func getTopologySpreadConstraints(cc *binaryomenv1alpha1.NodeSpec) []v1.TopologySpreadConstraint {
+	if cc.TopologyKey == "" {
+		return nil
+	}
+	return []v1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       cc.TopologyKey,
+			WhenUnsatisfiable: v1.DoNotSchedule,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app":  "druid",
+					"type": cc.NodeType,
+					"name": cc.Name,
+				},
+			},
+		},
+	}
+}
+
+// SpreadWithAntiAffinity replaces the topologySpreadConstraints of a pod template with preferred pod
+// anti-affinity on the same topology key, for api servers dropping the constraints
+func SpreadWithAntiAffinity(tpl *v1.PodTemplateSpec) {
+	if len(tpl.Spec.TopologySpreadConstraints) == 0 {
+		return
+	}
+	affinity := &v1.Affinity{}
+	if tpl.Spec.Affinity != nil {
+		affinity = tpl.Spec.Affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+	}
+	for _, constraint := range tpl.Spec.TopologySpreadConstraints {
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			v1.WeightedPodAffinityTerm{
+				Weight: 100,
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: constraint.LabelSelector,
+					TopologyKey:   constraint.TopologyKey,
+				},
+			})
+	}
+	tpl.Spec.Affinity = affinity
+	tpl.Spec.TopologySpreadConstraints = nil
+}
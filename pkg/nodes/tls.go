@@ -0,0 +1,224 @@
+package nodes
+
+import (
+	"fmt"
+	"time"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	tlsCertsVolumeName    = "tls-certs"
+	tlsKeystoreVolumeName = "tls-keystore"
+	tlsCertsMountPath     = "/druid/tls/certs"
+	tlsKeystoreMountPath  = "/druid/tls/keystore"
+	keystorePasswordEnv   = "KEYSTORE_PASSWORD"
+	keystorePasswordKey   = "password"
+	certAlias             = "druid"
+	defaultCertValidity   = 365 * 24 * time.Hour
+	defaultRenewBefore    = 30 * 24 * time.Hour
+
+	// TLSChecksumAnnotation on the pod template rolls the pods when their certificates change
+	TLSChecksumAnnotation = "druid.binaryomen.org/tls-checksum"
+)
+
+// keystoreScript builds the pkcs12 keystore and the jks truststore druid reads from the PEM secret
+const keystoreScript = `set -e
+openssl pkcs12 -export -name ` + certAlias + ` \
+  -in "$CERTS_DIR/tls.crt" -inkey "$CERTS_DIR/tls.key" -certfile "$CERTS_DIR/ca.crt" \
+  -out "$KEYSTORE_DIR/keystore.p12" -passout env:` + keystorePasswordEnv + `
+rm -f "$KEYSTORE_DIR/truststore.jks"
+keytool -importcert -noprompt -alias ca -file "$CERTS_DIR/ca.crt" \
+  -keystore "$KEYSTORE_DIR/truststore.jks" -storetype jks -storepass "$` + keystorePasswordEnv + `"
+`
+
+// MakeCASecretName returns the name of the Secret holding the operator CA
+func MakeCASecretName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("%s-ca", c.Name)
+}
+
+// MakeKeystorePasswordSecretName returns the name of the Secret holding the keystore password
+func MakeKeystorePasswordSecretName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("%s-keystore", c.Name)
+}
+
+// GetNodeTLSSecretName returns the Secret holding the certificate of a node
+func GetNodeTLSSecretName(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.TLS.SecretName != "" {
+		return c.Spec.TLS.SecretName
+	}
+	return fmt.Sprintf("%s-tls", cc.Name)
+}
+
+// GetCertValidity returns the validity of the operator issued node certificates
+func GetCertValidity(c *binaryomenv1alpha1.Druid) time.Duration {
+	if c.Spec.TLS.Validity != nil {
+		return c.Spec.TLS.Validity.Duration
+	}
+	return defaultCertValidity
+}
+
+// GetRenewBefore returns how long before their expiry node certificates are reissued, the default is
+// capped to a third of the validity so that short lived certificates are not reissued on every reconcile
+func GetRenewBefore(c *binaryomenv1alpha1.Druid) time.Duration {
+	if c.Spec.TLS.RenewBefore != nil {
+		return c.Spec.TLS.RenewBefore.Duration
+	}
+	if validity := GetCertValidity(c); defaultRenewBefore > validity/3 {
+		return validity / 3
+	}
+	return defaultRenewBefore
+}
+
+// GetNodeDNSNames lists the names a node is reached by, its service and, for statefulsets, its pods
+func GetNodeDNSNames(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []string {
+	names := []string{
+		cc.Name,
+		fmt.Sprintf("%s.%s", cc.Name, c.Namespace),
+		fmt.Sprintf("%s.%s.svc", cc.Name, c.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", cc.Name, c.Namespace),
+	}
+	if usesStatefulSet(cc) {
+		names = append(names,
+			fmt.Sprintf("*.%s.%s.svc", makeHeadlessServiceName(cc), c.Namespace),
+			fmt.Sprintf("*.%s.%s.svc.cluster.local", makeHeadlessServiceName(cc), c.Namespace),
+		)
+	}
+	return names
+}
+
+// MakeTLSSecret holds a certificate with its key and CA in the kubernetes.io/tls layout
+func MakeTLSSecret(name string, c *binaryomenv1alpha1.Druid, caCert []byte, cert []byte, key []byte) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":            caCert,
+			v1.TLSCertKey:       cert,
+			v1.TLSPrivateKeyKey: key,
+		},
+	}
+}
+
+// MakeKeystorePasswordSecret holds the password of the keystores built in the pods
+func MakeKeystorePasswordSecret(c *binaryomenv1alpha1.Druid, password string) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MakeKeystorePasswordSecretName(c),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				"app": "druid",
+			},
+		},
+		Data: map[string][]byte{
+			keystorePasswordKey: []byte(password),
+		},
+	}
+}
+
+// announcesDNSName tells whether nodes announce a dns name rather than their pod ip, which the certificates
+// behind SecretName cannot cover
+func announcesDNSName(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.TLS != nil && c.Spec.TLS.SecretName != ""
+}
+
+// SetPodTemplateAnnotation sets an annotation computed by the controller, eg TLSChecksumAnnotation
+func SetPodTemplateAnnotation(tpl *v1.PodTemplateSpec, key string, value string) {
+	annotations := map[string]string{}
+	for k, val := range tpl.Annotations {
+		annotations[k] = val
+	}
+	annotations[key] = value
+	tpl.Annotations = annotations
+}
+
+// getTLSProperties points the druid server and client at the keystores, the password is read from the
+// environment. Nodes announce their pod ip, which deployment pods have no stable dns name for, so hostnames
+// are left unchecked for the operator CA, which issues nothing but the node certificates of the cluster.
+// A shared CA behind SecretName keeps validating them, nodes then announce dns names, see getHost
+func getTLSProperties(c *binaryomenv1alpha1.Druid) []property {
+	if c.Spec.TLS == nil {
+		return nil
+	}
+	password := makePasswordProvider(keystorePasswordEnv)
+	props := []property{
+		{key: "druid.server.https.keyStoreType", value: "pkcs12"},
+		{key: "druid.server.https.keyStorePath", value: fmt.Sprintf("%s/keystore.p12", tlsKeystoreMountPath)},
+		{key: "druid.server.https.keyStorePassword", value: password},
+		{key: "druid.server.https.certAlias", value: certAlias},
+		{key: "druid.client.https.protocol", value: "TLSv1.2"},
+		{key: "druid.client.https.trustStoreType", value: "jks"},
+		{key: "druid.client.https.trustStorePath", value: fmt.Sprintf("%s/truststore.jks", tlsKeystoreMountPath)},
+		{key: "druid.client.https.trustStorePassword", value: password},
+	}
+	if c.Spec.TLS.SecretName == "" {
+		props = append(props, property{key: "druid.client.https.validateHostnames", value: "false"})
+	}
+	return props
+}
+
+func getKeystorePasswordEnv(c *binaryomenv1alpha1.Druid) []v1.EnvVar {
+	if c.Spec.TLS == nil {
+		return nil
+	}
+	return []v1.EnvVar{
+		makeSecretEnv(keystorePasswordEnv, MakeKeystorePasswordSecretName(c), keystorePasswordKey),
+	}
+}
+
+func makeKeystoreContainer(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) v1.Container {
+	return v1.Container{
+		Name:    "keystore",
+		Image:   c.Spec.TLS.Image,
+		Command: []string{"sh", "-c", keystoreScript},
+		Env: append([]v1.EnvVar{
+			{Name: "CERTS_DIR", Value: tlsCertsMountPath},
+			{Name: "KEYSTORE_DIR", Value: tlsKeystoreMountPath},
+		}, getKeystorePasswordEnv(c)...),
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "File",
+		VolumeMounts: []v1.VolumeMount{
+			{Name: tlsCertsVolumeName, MountPath: tlsCertsMountPath, ReadOnly: true},
+			{Name: tlsKeystoreVolumeName, MountPath: tlsKeystoreMountPath},
+		},
+	}
+}
+
+func makeTLSVolumes(cc *binaryomenv1alpha1.NodeSpec, c *binaryomenv1alpha1.Druid) []v1.Volume {
+	return []v1.Volume{
+		{
+			Name: tlsCertsVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: GetNodeTLSSecretName(cc, c)},
+			},
+		},
+		{
+			Name:         tlsKeystoreVolumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		},
+	}
+}
+
+func makeTLSVolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      tlsKeystoreVolumeName,
+		MountPath: tlsKeystoreMountPath,
+		ReadOnly:  true,
+	}
+}
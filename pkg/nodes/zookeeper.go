@@ -0,0 +1,225 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	zookeeperClientPort   int32 = 2181
+	zookeeperFollowerPort int32 = 2888
+	zookeeperElectionPort int32 = 3888
+	defaultZookeeperImage       = "zookeeper:3.5"
+)
+
+// zookeeperScript derives the member id from the statefulset ordinal before handing over to the image entrypoint
+const zookeeperScript = `export ZOO_MY_ID=$((${HOSTNAME##*-}+1))
+exec /docker-entrypoint.sh zkServer.sh start-foreground`
+
+// IsZookeeperManaged reports whether the operator runs the zookeeper ensemble of the cluster
+func IsZookeeperManaged(c *binaryomenv1alpha1.Druid) bool {
+	return c.Spec.Zookeeper != nil && c.Spec.Zookeeper.Type == binaryomenv1alpha1.ZookeeperManaged
+}
+
+// GetZookeeperConnectString lists the members of the managed ensemble
+func GetZookeeperConnectString(c *binaryomenv1alpha1.Druid) string {
+	hosts := []string{}
+	for i := int32(0); i < getZookeeperReplicas(c); i++ {
+		hosts = append(hosts, fmt.Sprintf("%s:%d", makeZookeeperHost(c, i), zookeeperClientPort))
+	}
+	return strings.Join(hosts, ",")
+}
+
+func getZookeeperProperties(c *binaryomenv1alpha1.Druid) []property {
+	if !IsZookeeperManaged(c) {
+		return nil
+	}
+	return []property{
+		{key: "druid.zk.service.host", value: GetZookeeperConnectString(c)},
+	}
+}
+
+func makeZookeeperName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("%s-zookeeper", c.Name)
+}
+
+func makeZookeeperHeadlessName(c *binaryomenv1alpha1.Druid) string {
+	return fmt.Sprintf("%s-headless", makeZookeeperName(c))
+}
+
+func makeZookeeperHost(c *binaryomenv1alpha1.Druid, ordinal int32) string {
+	return fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local", makeZookeeperName(c), ordinal, makeZookeeperHeadlessName(c), c.Namespace)
+}
+
+func makeZookeeperLabels(c *binaryomenv1alpha1.Druid) map[string]string {
+	return map[string]string{
+		"app":  "zookeeper",
+		"name": makeZookeeperName(c),
+	}
+}
+
+func getZookeeperReplicas(c *binaryomenv1alpha1.Druid) int32 {
+	if c.Spec.Zookeeper.Replicas < 1 {
+		return 1
+	}
+	return c.Spec.Zookeeper.Replicas
+}
+
+func getZookeeperImage(c *binaryomenv1alpha1.Druid) string {
+	if c.Spec.Zookeeper.Image != "" {
+		return c.Spec.Zookeeper.Image
+	}
+	return defaultZookeeperImage
+}
+
+// getZookeeperServers renders ZOO_SERVERS for the zookeeper image
+func getZookeeperServers(c *binaryomenv1alpha1.Druid) string {
+	servers := []string{}
+	for i := int32(0); i < getZookeeperReplicas(c); i++ {
+		servers = append(servers, fmt.Sprintf("server.%d=%s:%d:%d;%d", i+1, makeZookeeperHost(c, i), zookeeperFollowerPort, zookeeperElectionPort, zookeeperClientPort))
+	}
+	return strings.Join(servers, " ")
+}
+
+// MakeZookeeperStatefulSet for the managed zookeeper ensemble
+func MakeZookeeperStatefulSet(c *binaryomenv1alpha1.Druid) *appsv1.StatefulSet {
+	replicas := getZookeeperReplicas(c)
+
+	sts := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeZookeeperName(c),
+			Namespace: c.Namespace,
+			Labels:    makeZookeeperLabels(c),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: makeZookeeperHeadlessName(c),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: makeZookeeperLabels(c),
+			},
+			Replicas:            &replicas,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: makeZookeeperLabels(c),
+				},
+				Spec: v1.PodSpec{
+					ImagePullSecrets: c.Spec.ImagePullSecrets,
+					Containers: []v1.Container{
+						{
+							Name:      "zookeeper",
+							Image:     getZookeeperImage(c),
+							Command:   []string{"sh", "-c", zookeeperScript},
+							Resources: c.Spec.Zookeeper.Resources,
+							Env: []v1.EnvVar{
+								{
+									Name:  "ZOO_SERVERS",
+									Value: getZookeeperServers(c),
+								},
+							},
+							Ports: []v1.ContainerPort{
+								{Name: "client", ContainerPort: zookeeperClientPort, Protocol: v1.ProtocolTCP},
+								{Name: "follower", ContainerPort: zookeeperFollowerPort, Protocol: v1.ProtocolTCP},
+								{Name: "election", ContainerPort: zookeeperElectionPort, Protocol: v1.ProtocolTCP},
+							},
+							ReadinessProbe: &v1.Probe{
+								Handler: v1.Handler{
+									TCPSocket: &v1.TCPSocketAction{
+										Port: intstr.FromInt(int(zookeeperClientPort)),
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+							TerminationMessagePath:   "/dev/termination-log",
+							TerminationMessagePolicy: "File",
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/data",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if c.Spec.Zookeeper.Storage != nil {
+		sts.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec:       *c.Spec.Zookeeper.Storage,
+			},
+		}
+	} else {
+		sts.Spec.Template.Spec.Volumes = []v1.Volume{
+			{
+				Name:         "data",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			},
+		}
+	}
+
+	return sts
+}
+
+// MakeZookeeperService creates the headless service governing the zookeeper statefulset,
+// not ready members are published so the ensemble can form a quorum
+func MakeZookeeperService(c *binaryomenv1alpha1.Druid) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeZookeeperHeadlessName(c),
+			Namespace: c.Namespace,
+			Labels:    makeZookeeperLabels(c),
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "client", Port: zookeeperClientPort, TargetPort: intstr.FromInt(int(zookeeperClientPort))},
+				{Name: "follower", Port: zookeeperFollowerPort, TargetPort: intstr.FromInt(int(zookeeperFollowerPort))},
+				{Name: "election", Port: zookeeperElectionPort, TargetPort: intstr.FromInt(int(zookeeperElectionPort))},
+			},
+			Selector:                 makeZookeeperLabels(c),
+			ClusterIP:                v1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
+// MakeZookeeperPodDisruptionBudget keeps the quorum during voluntary disruptions
+func MakeZookeeperPodDisruptionBudget(c *binaryomenv1alpha1.Druid) *v1beta1.PodDisruptionBudget {
+	return &v1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "policy/v1beta1",
+			Kind:       "PodDisruptionBudget",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeZookeeperName(c),
+			Namespace: c.Namespace,
+			Labels:    makeZookeeperLabels(c),
+		},
+		Spec: v1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: makeZookeeperLabels(c),
+			},
+			MaxUnavailable: &intstr.IntOrString{
+				Type:   intstr.Type(0),
+				IntVal: int32(1),
+			},
+		},
+	}
+}
@@ -1,44 +1,169 @@
 package sync
 
 import (
+	"github.com/BinaryOmen/druid-operator/pkg/nodes"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
-	extensions "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // SyncStatefulSet synchronizes any updates to the stateful-set
-// Use Deepcopy funcs
 func SyncStatefulSet(curr *appsv1.StatefulSet, next *appsv1.StatefulSet) {
-	currSts := curr.DeepCopy()
-	currSts.Spec.Replicas = next.Spec.Replicas
-	currSts.Spec.Template = next.Spec.Template
-	currSts.Spec.UpdateStrategy = next.Spec.UpdateStrategy
-
+	curr.Spec.Replicas = next.Spec.Replicas
+	curr.Spec.Template = next.Spec.Template
+	curr.Spec.UpdateStrategy = next.Spec.UpdateStrategy
 }
 
 // SyncDeployment shall sync deployment
 func SyncDeployment(curr *appsv1.Deployment, next *appsv1.Deployment) {
-	currDeployment := curr.DeepCopy()
-	currDeployment.Spec.Replicas = next.Spec.Replicas
-	currDeployment.Spec.Template = next.Spec.Template
+	curr.Spec.Replicas = next.Spec.Replicas
+	curr.Spec.Template = next.Spec.Template
 }
 
-// SyncService shall sync service
+// SyncService shall sync service, the fields allocated by the api server and cloud controllers are kept: the
+// ClusterIP, the node ports and health check port while the type still needs them, and annotations or labels
+// the operator never rendered. Those rendered by the previous update and no longer rendered are removed
 func SyncService(curr *v1.Service, next *v1.Service) {
-	currSvc := curr.DeepCopy()
-	currSvc.Spec.Ports = next.Spec.Ports
-	currSvc.Spec.Type = next.Spec.Type
+	appliedLabels := nodes.GetAppliedKeys(curr.Annotations, nodes.AppliedLabelsAnnotation)
+	appliedAnnotations := nodes.GetAppliedKeys(curr.Annotations, nodes.AppliedAnnotationsAnnotation)
+	curr.Labels = mergeStrings(pruneStrings(curr.Labels, appliedLabels, next.Labels), next.Labels)
+	curr.Annotations = mergeStrings(pruneStrings(curr.Annotations, appliedAnnotations, next.Annotations), next.Annotations)
+
+	exposed := next.Spec.Type == v1.ServiceTypeNodePort || next.Spec.Type == v1.ServiceTypeLoadBalancer
+	ports := []v1.ServicePort{}
+	for _, port := range next.Spec.Ports {
+		if port.NodePort == 0 && exposed {
+			port.NodePort = getNodePort(curr.Spec.Ports, port)
+		}
+		ports = append(ports, port)
+	}
+	healthCheckNodePort := next.Spec.HealthCheckNodePort
+	if healthCheckNodePort == 0 && next.Spec.Type == v1.ServiceTypeLoadBalancer && next.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal {
+		healthCheckNodePort = curr.Spec.HealthCheckNodePort
+	}
+
+	curr.Spec.Ports = ports
+	curr.Spec.Type = next.Spec.Type
+	curr.Spec.Selector = next.Spec.Selector
+	curr.Spec.PublishNotReadyAddresses = next.Spec.PublishNotReadyAddresses
+	curr.Spec.LoadBalancerSourceRanges = next.Spec.LoadBalancerSourceRanges
+	curr.Spec.LoadBalancerIP = next.Spec.LoadBalancerIP
+	curr.Spec.ExternalTrafficPolicy = next.Spec.ExternalTrafficPolicy
+	curr.Spec.HealthCheckNodePort = healthCheckNodePort
+	curr.Spec.SessionAffinity = next.Spec.SessionAffinity
+	curr.Spec.SessionAffinityConfig = next.Spec.SessionAffinityConfig
+}
+
+// getNodePort returns the node port allocated to the same port, matched by name then by port number so that
+// naming a formerly unnamed port, eg when the metrics port is added, keeps its node port
+func getNodePort(curr []v1.ServicePort, port v1.ServicePort) int32 {
+	for _, cur := range curr {
+		if cur.Name == port.Name && cur.Port == port.Port {
+			return cur.NodePort
+		}
+	}
+	for _, cur := range curr {
+		if cur.Port == port.Port && getProtocol(cur) == getProtocol(port) {
+			return cur.NodePort
+		}
+	}
+	return 0
+}
+
+// getProtocol defaults the protocol of a rendered port like the api server does
+func getProtocol(port v1.ServicePort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// mergeStrings sets the next values over the current ones
+func mergeStrings(curr map[string]string, next map[string]string) map[string]string {
+	if len(next) == 0 {
+		return curr
+	}
+	merged := map[string]string{}
+	for k, v := range curr {
+		merged[k] = v
+	}
+	for k, v := range next {
+		merged[k] = v
+	}
+	return merged
+}
+
+// pruneStrings removes the applied keys the next values no longer set
+func pruneStrings(curr map[string]string, applied []string, next map[string]string) map[string]string {
+	if len(applied) == 0 {
+		return curr
+	}
+	pruned := map[string]string{}
+	for k, v := range curr {
+		pruned[k] = v
+	}
+	for _, k := range applied {
+		if _, ok := next[k]; !ok {
+			delete(pruned, k)
+		}
+	}
+	return pruned
 }
 
 // SyncCm shall sync Cm
 func SyncCm(curr *v1.ConfigMap, next *v1.ConfigMap) {
-	currCm := curr.DeepCopy()
-	currCm.Data = next.Data
-	currCm.BinaryData = next.BinaryData
+	curr.Data = next.Data
+	curr.BinaryData = next.BinaryData
+}
+
+// SyncIngress shall sync the ingress labels, annotations and spec
+func SyncIngress(curr *unstructured.Unstructured, next *unstructured.Unstructured) {
+	curr.SetLabels(next.GetLabels())
+	curr.SetAnnotations(next.GetAnnotations())
+	curr.Object["spec"] = runtime.DeepCopyJSONValue(next.Object["spec"])
+}
+
+// SyncHTTPRoute shall sync the httproute labels and spec
+func SyncHTTPRoute(curr *unstructured.Unstructured, next *unstructured.Unstructured) {
+	curr.SetLabels(next.GetLabels())
+	curr.Object["spec"] = runtime.DeepCopyJSONValue(next.Object["spec"])
+}
+
+// SyncNetworkPolicy shall sync the networkpolicy labels and spec
+func SyncNetworkPolicy(curr *networkingv1.NetworkPolicy, next *networkingv1.NetworkPolicy) {
+	curr.Labels = next.Labels
+	curr.Spec = next.Spec
+}
+
+// SyncRoute shall sync the route labels, annotations and spec, keeping the host generated by OpenShift
+func SyncRoute(curr *unstructured.Unstructured, next *unstructured.Unstructured) {
+	curr.SetLabels(next.GetLabels())
+	curr.SetAnnotations(mergeStrings(curr.GetAnnotations(), next.GetAnnotations()))
+
+	spec := runtime.DeepCopyJSONValue(next.Object["spec"]).(map[string]interface{})
+	if _, ok := spec["host"]; !ok {
+		if host, ok, _ := unstructured.NestedString(curr.Object, "spec", "host"); ok {
+			spec["host"] = host
+		}
+	}
+	curr.Object["spec"] = spec
+}
+
+// SyncMonitor shall sync the servicemonitor or podmonitor labels and spec
+func SyncMonitor(curr *unstructured.Unstructured, next *unstructured.Unstructured) {
+	curr.SetLabels(next.GetLabels())
+	curr.Object["spec"] = runtime.DeepCopyJSONValue(next.Object["spec"])
+}
+
+// SyncRole shall sync role rules
+func SyncRole(curr *rbacv1.Role, next *rbacv1.Role) {
+	curr.Rules = next.Rules
 }
 
-func SyncIngress(curr *extensions.Ingress, next *extensions.Ingress) {
-	currIng := curr.DeepCopy()
-	currIng.Annotations = next.Annotations
-	currIng.Spec = next.Spec
+// SyncRoleBinding shall sync rolebinding subjects, the roleRef is immutable
+func SyncRoleBinding(curr *rbacv1.RoleBinding, next *rbacv1.RoleBinding) {
+	curr.Subjects = next.Subjects
 }
@@ -1,12 +1,23 @@
 package validation
 
 import (
+	"fmt"
+	"net"
+	"path"
+	"path/filepath"
+	"strings"
+
 	binaryomenv1alpha1 "github.com/BinaryOmen/druid-operator/pkg/apis/binaryomen/v1alpha1"
+	"github.com/BinaryOmen/druid-operator/pkg/nodes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 type Validator struct {
-	Validated    bool
-	ErrorMessage string
+	Validated      bool
+	ErrorMessage   string
+	WarningMessage string
 }
 
 // Validate Druid Spec
@@ -33,6 +44,96 @@ func (v *Validator) Validate(c *binaryomenv1alpha1.Druid) {
 		v.Validated = false
 	}
 
+	if nodes.RewritesLoadList(c) {
+		if _, err := nodes.GetUserLoadList(c); err != nil {
+			v.ErrorMessage = v.ErrorMessage + err.Error() + " in CommonRuntimeProperties\n"
+			v.Validated = false
+		}
+	}
+
+	if c.Spec.Discovery != "" && c.Spec.Discovery != binaryomenv1alpha1.DiscoveryZookeeper && c.Spec.Discovery != binaryomenv1alpha1.DiscoveryKubernetes {
+		v.ErrorMessage = v.ErrorMessage + "Discovery must be zookeeper or kubernetes in Druid Cluster Spec\n"
+		v.Validated = false
+	}
+
+	if c.Spec.Zookeeper != nil {
+		if c.Spec.Zookeeper.Type != binaryomenv1alpha1.ZookeeperExternal && c.Spec.Zookeeper.Type != binaryomenv1alpha1.ZookeeperManaged {
+			v.ErrorMessage = v.ErrorMessage + "Zookeeper Type must be external or managed in Druid Zookeeper Spec\n"
+			v.Validated = false
+		}
+
+		if nodes.IsZookeeperManaged(c) {
+			if c.Spec.Discovery == binaryomenv1alpha1.DiscoveryKubernetes {
+				v.ErrorMessage = v.ErrorMessage + "Managed Zookeeper cannot be used with kubernetes Discovery in Druid Cluster Spec\n"
+				v.Validated = false
+			}
+			if nodes.HasCommonProperty(c, "druid.zk.service.host") {
+				v.ErrorMessage = v.ErrorMessage + "druid.zk.service.host must not be set in CommonRuntimeProperties with managed Zookeeper\n"
+				v.Validated = false
+			}
+		}
+	}
+
+	if c.Spec.Extensions != nil {
+		for _, coordinate := range c.Spec.Extensions.Coordinates {
+			if _, err := nodes.GetExtensionArtifact(coordinate); err != nil {
+				v.ErrorMessage = v.ErrorMessage + err.Error() + " in Druid Extensions Spec\n"
+				v.Validated = false
+			}
+		}
+
+		if c.Spec.Extensions.MountPath != "" && !path.IsAbs(c.Spec.Extensions.MountPath) {
+			v.ErrorMessage = v.ErrorMessage + "Extensions MountPath must be absolute in Druid Extensions Spec\n"
+			v.Validated = false
+		}
+	}
+
+	if c.Spec.DriftPolicy != "" && c.Spec.DriftPolicy != binaryomenv1alpha1.DriftCorrect && c.Spec.DriftPolicy != binaryomenv1alpha1.DriftReport {
+		v.ErrorMessage = v.ErrorMessage + "DriftPolicy must be Correct or Report in Druid Cluster Spec\n"
+		v.Validated = false
+	}
+
+	if c.Spec.TLS != nil {
+		if c.Spec.TLS.Image == "" {
+			v.ErrorMessage = v.ErrorMessage + "Image with openssl and keytool missing from Druid TLS Spec\n"
+			v.Validated = false
+		}
+		for _, d := range []*metav1.Duration{c.Spec.TLS.Validity, c.Spec.TLS.RenewBefore} {
+			if d != nil && d.Duration <= 0 {
+				v.ErrorMessage = v.ErrorMessage + "Validity and RenewBefore must be positive in Druid TLS Spec\n"
+				v.Validated = false
+			}
+		}
+		if c.Spec.TLS.SecretName != "" {
+			v.WarningMessage = v.WarningMessage + fmt.Sprintf("Nodes announce the dns names of their pods or services with TLS SecretName [%s], its certificate shall cover them\n", c.Spec.TLS.SecretName)
+		}
+		if nodes.GetRenewBefore(c) >= nodes.GetCertValidity(c) {
+			v.ErrorMessage = v.ErrorMessage + "RenewBefore must be shorter than Validity in Druid TLS Spec\n"
+			v.Validated = false
+		}
+	}
+
+	if c.Spec.Authentication != nil && strings.ContainsAny(c.Spec.Authentication.Name, ". \t\"") {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid authenticator Name [%s] in Druid Authentication Spec\n", c.Spec.Authentication.Name)
+		v.Validated = false
+	}
+
+	if nodes.IsMonitoringEnabled(c) {
+		v.validateMonitoring(c)
+	}
+
+	tierPriorities := map[string]int32{}
+	for _, n := range c.Spec.Nodes {
+		if n.NodeType != "historical" {
+			continue
+		}
+		tier, priority := nodes.GetTier(&n)
+		if p, ok := tierPriorities[tier]; ok && p != priority {
+			v.WarningMessage = v.WarningMessage + fmt.Sprintf("Historical nodes of tier [%s] announce different priorities\n", tier)
+		}
+		tierPriorities[tier] = priority
+	}
+
 	for _, n := range c.Spec.Nodes {
 		//TODO: match strings, range slice for node types
 		if n.NodeType == "" {
@@ -55,22 +156,339 @@ func (v *Validator) Validate(c *binaryomenv1alpha1.Druid) {
 			v.Validated = false
 		}
 
-		if n.Service.Port == 0 || n.Service.TargetPort == 0 {
+		v.validateServiceOptions(&n)
+		if len(n.Service.Ports) > 0 {
+			v.validateServicePorts(&n)
+		} else if n.Service.Port == 0 || n.Service.TargetPort == 0 {
 			v.ErrorMessage = v.ErrorMessage + "Service is missing in Druid Node Spec\n"
 			v.Validated = false
 		}
 
+		if target, ok := nodes.GetServicePort(&n, binaryomenv1alpha1.PortPlaintext); ok {
+			if port, ok := nodes.GetNodeProperty(&n, "druid.plaintextPort"); ok && port != fmt.Sprintf("%d", target.TargetPort) {
+				v.WarningMessage = v.WarningMessage + fmt.Sprintf("druid.plaintextPort [%s] differs from Service TargetPort [%d] in Druid Node Spec [%s]\n", port, target.TargetPort, n.Name)
+			}
+		} else if _, ok := nodes.GetNodeProperty(&n, "druid.plaintextPort"); ok {
+			v.WarningMessage = v.WarningMessage + fmt.Sprintf("druid.plaintextPort is set but Service has no plaintext port in Druid Node Spec [%s]\n", n.Name)
+		}
+		if _, ok := nodes.GetServicePort(&n, binaryomenv1alpha1.PortTLS); !ok && c.Spec.TLS != nil {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("TLS needs a tls Service port in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+		if target, ok := nodes.GetServicePort(&n, binaryomenv1alpha1.PortTLS); ok {
+			if port, ok := nodes.GetNodeProperty(&n, "druid.tlsPort"); ok && port != fmt.Sprintf("%d", target.TargetPort) {
+				v.WarningMessage = v.WarningMessage + fmt.Sprintf("druid.tlsPort [%s] differs from Service tls TargetPort [%d] in Druid Node Spec [%s]\n", port, target.TargetPort, n.Name)
+			}
+		}
+
+		if service, ok := nodes.GetNodeProperty(&n, "druid.service"); ok && service != fmt.Sprintf("druid/%s", n.NodeType) {
+			v.WarningMessage = v.WarningMessage + fmt.Sprintf("druid.service [%s] differs from NodeType [%s] in Druid Node Spec [%s]\n", service, n.NodeType, n.Name)
+		}
+
+		if n.MemoryModel != nil {
+			if m, err := nodes.ComputeMemorySettings(&n); err != nil {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("%s in Druid Node Spec [%s]\n", err.Error(), n.Name)
+				v.Validated = false
+			} else if m.Heap+m.DirectMemory > m.Limit {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Heap [%d] plus direct memory [%d] exceed the memory limit [%d] in Druid Node Spec [%s]\n", m.Heap, m.DirectMemory, m.Limit, n.Name)
+				v.Validated = false
+			}
+		}
+
+		if (n.Tier != "" || n.TierPriority != nil) && n.NodeType != "historical" {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Tier is only supported by historical nodes in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+
+		switch n.PVCRetentionPolicy {
+		case "", binaryomenv1alpha1.PVCRetain:
+		case binaryomenv1alpha1.PVCDeleteOnScaleDown, binaryomenv1alpha1.PVCDeleteOnClusterDelete:
+			if n.NodeType != "historical" && n.NodeType != "middleManager" {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("PVCRetentionPolicy is only supported by historical and middleManager nodes in Druid Node Spec [%s]\n", n.Name)
+				v.Validated = false
+			}
+		default:
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid PVCRetentionPolicy [%s] in Druid Node Spec [%s], must be one of Retain, DeleteOnScaleDown, DeleteOnClusterDelete\n", n.PVCRetentionPolicy, n.Name)
+			v.Validated = false
+		}
+
+		if n.SegmentCache != nil {
+			v.validateSegmentCache(&n)
+		}
+
 		if n.Name == "" {
 			v.ErrorMessage = v.ErrorMessage + "Node Name missing in Druid Node Spec\n"
 			v.Validated = false
 		}
 
 		if n.Ingress.Enabled == true {
-			if n.Ingress.Hostname == "" {
-				v.ErrorMessage = v.ErrorMessage + "Hostname missing in Druid Node Ingress Spec\n"
+			v.validateIngress(&n)
+		}
+
+		if n.Gateway != nil {
+			v.validateGateway(&n)
+		}
+
+		if n.Route != nil {
+			v.validateRoute(&n)
+		}
+
+		if n.Replicas > 1 && nodes.AnnouncesServiceName(&n, c) {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Replicas would announce the same service name with TLS SecretName, use one replica or the operator CA in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+
+		if c.Spec.NetworkPolicy != nil && c.Spec.NetworkPolicy.Enabled && n.NodeType == "router" && len(n.NetworkPolicyIngress) == 0 && len(c.Spec.NetworkPolicy.Ingress) == 0 {
+			v.WarningMessage = v.WarningMessage + fmt.Sprintf("NetworkPolicy denies every client of router [%s], add the ingress controller to its NetworkPolicyIngress\n", n.Name)
+		}
+
+	}
+}
+
+// validateSegmentCache fails when the segment cache would not fit in the claim
+func (v *Validator) validateSegmentCache(n *binaryomenv1alpha1.NodeSpec) {
+	if n.NodeType != "historical" {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("SegmentCache is only supported by historical nodes in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+		return
+	}
+
+	if !path.IsAbs(n.SegmentCache.MountPath) {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("SegmentCache MountPath must be absolute in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+
+	if vm := nodes.GetVolumeMount(n, n.SegmentCache.VolumeClaimTemplate); vm != nil {
+		if rel, err := filepath.Rel(vm.MountPath, n.SegmentCache.MountPath); err != nil || strings.HasPrefix(rel, "..") {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("SegmentCache MountPath [%s] is outside the mount [%s] of its claim in Druid Node Spec [%s]\n", n.SegmentCache.MountPath, vm.MountPath, n.Name)
+			v.Validated = false
+		}
+	}
+
+	size, err := nodes.ComputeSegmentCacheSize(n)
+	if err != nil {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("%s in Druid Node Spec [%s]\n", err.Error(), n.Name)
+		v.Validated = false
+		return
+	}
+
+	if userSize, ok, err := nodes.GetUserSegmentCacheSize(n); err != nil {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("%s in Druid Node Spec [%s]\n", err.Error(), n.Name)
+		v.Validated = false
+	} else if ok && userSize > size {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("druid.segmentCache.locations [%d] do not fit the segment cache claim [%d] in Druid Node Spec [%s]\n", userSize, size, n.Name)
+		v.Validated = false
+	}
+
+	if maxSize, ok := nodes.GetNodeProperty(n, "druid.server.maxSize"); ok {
+		if userMaxSize, err := nodes.ParseHumanReadableBytes(maxSize); err != nil || userMaxSize > size {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("druid.server.maxSize [%s] does not fit the segment cache claim [%d] in Druid Node Spec [%s]\n", maxSize, size, n.Name)
+			v.Validated = false
+		}
+	}
+}
+
+// validateIngress checks every rule has a host and paths of a known type, routed to a named Service port
+func (v *Validator) validateIngress(n *binaryomenv1alpha1.NodeSpec) {
+	if len(n.Ingress.Rules) == 0 && n.Ingress.Hostname == "" {
+		v.ErrorMessage = v.ErrorMessage + "Hostname missing in Druid Node Ingress Spec\n"
+		v.Validated = false
+	}
+	if !isValidPathType(n.Ingress.PathType) {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Ingress PathType [%s] in Druid Node Spec [%s]\n", n.Ingress.PathType, n.Name)
+		v.Validated = false
+	}
+	for _, rule := range n.Ingress.Rules {
+		if rule.Host == "" || len(rule.Paths) == 0 {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Ingress rules need a host and paths in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+		for _, path := range rule.Paths {
+			if !strings.HasPrefix(path.Path, "/") {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Ingress path [%s] of host [%s] must be absolute in Druid Node Spec [%s]\n", path.Path, rule.Host, n.Name)
+				v.Validated = false
+			}
+			if !isValidPathType(path.PathType) {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Ingress PathType [%s] in Druid Node Spec [%s]\n", path.PathType, n.Name)
+				v.Validated = false
+			}
+			if _, ok := nodes.GetServicePort(n, path.TargetPort); path.TargetPort != "" && !ok {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Ingress path TargetPort [%s] is not a Service port in Druid Node Spec [%s]\n", path.TargetPort, n.Name)
 				v.Validated = false
 			}
 		}
+	}
+}
 
+// validateGateway checks the route of a router or broker references a Gateway and a Service port
+func (v *Validator) validateGateway(n *binaryomenv1alpha1.NodeSpec) {
+	if n.NodeType != "router" && n.NodeType != "broker" {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Gateway is only supported by router and broker nodes in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+	if n.Gateway.ParentRef.Name == "" {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Gateway ParentRef Name missing in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+	for _, m := range n.Gateway.Matches {
+		switch m.PathType {
+		case "", "Exact", "PathPrefix", "RegularExpression":
+		default:
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Gateway PathType [%s] in Druid Node Spec [%s]\n", m.PathType, n.Name)
+			v.Validated = false
+		}
+		if m.Path != "" && m.PathType != "RegularExpression" && !strings.HasPrefix(m.Path, "/") {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Gateway path [%s] must be absolute in Druid Node Spec [%s]\n", m.Path, n.Name)
+			v.Validated = false
+		}
+	}
+	if _, ok := nodes.GetHTTPRouteBackendPort(n); !ok {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Gateway TargetPort [%s] is not a Service port in Druid Node Spec [%s]\n", n.Gateway.TargetPort, n.Name)
+		v.Validated = false
+	}
+}
+
+// validateRoute checks the OpenShift Route termination and target port
+func (v *Validator) validateRoute(n *binaryomenv1alpha1.NodeSpec) {
+	if n.Route.TargetPort != "" {
+		if _, ok := nodes.GetServicePort(n, n.Route.TargetPort); !ok || len(n.Service.Ports) == 0 {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Route TargetPort [%s] is not a Service port in Druid Node Spec [%s]\n", n.Route.TargetPort, n.Name)
+			v.Validated = false
+		}
+	}
+	if n.Route.Path != "" && !strings.HasPrefix(n.Route.Path, "/") {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Route Path [%s] must be absolute in Druid Node Spec [%s]\n", n.Route.Path, n.Name)
+		v.Validated = false
+	}
+	tls := n.Route.TLS
+	if tls == nil {
+		return
+	}
+	switch tls.Termination {
+	case "edge", "reencrypt":
+	case "passthrough":
+		if n.Route.Path != "" {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Route Path is not supported by passthrough termination in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+	default:
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Route termination [%s] in Druid Node Spec [%s], must be one of edge, passthrough, reencrypt\n", tls.Termination, n.Name)
+		v.Validated = false
+	}
+	switch tls.InsecureEdgeTerminationPolicy {
+	case "", "None", "Redirect":
+	case "Allow":
+		if tls.Termination != "edge" {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Insecure traffic is only allowed by edge termination in Druid Node Spec [%s]\n", n.Name)
+			v.Validated = false
+		}
+	default:
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Route InsecureEdgeTerminationPolicy [%s] in Druid Node Spec [%s]\n", tls.InsecureEdgeTerminationPolicy, n.Name)
+		v.Validated = false
+	}
+	if tls.DestinationCACertificate != "" && tls.Termination != "reencrypt" {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("DestinationCACertificate is only used by reencrypt termination in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+}
+
+func isValidPathType(pathType string) bool {
+	switch pathType {
+	case "", "Exact", "Prefix", "ImplementationSpecific":
+		return true
+	}
+	return false
+}
+
+// validateServiceOptions checks the load balancing options match the service type
+func (v *Validator) validateServiceOptions(n *binaryomenv1alpha1.NodeSpec) {
+	s := n.Service
+	exposed := s.Type == corev1.ServiceTypeNodePort || s.Type == corev1.ServiceTypeLoadBalancer
+	if s.ExternalTrafficPolicy != "" && (!exposed || (s.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeCluster && s.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal)) {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("ExternalTrafficPolicy [%s] needs a NodePort or LoadBalancer Service in Druid Node Spec [%s]\n", s.ExternalTrafficPolicy, n.Name)
+		v.Validated = false
+	}
+	if (len(s.LoadBalancerSourceRanges) > 0 || s.LoadBalancerIP != "") && s.Type != corev1.ServiceTypeLoadBalancer {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("LoadBalancer options need a LoadBalancer Service in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+	for _, cidr := range s.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid LoadBalancerSourceRange [%s] in Druid Node Spec [%s]\n", cidr, n.Name)
+			v.Validated = false
+		}
+	}
+	if s.SessionAffinity != "" && s.SessionAffinity != corev1.ServiceAffinityNone && s.SessionAffinity != corev1.ServiceAffinityClientIP {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid SessionAffinity [%s] in Druid Node Spec [%s]\n", s.SessionAffinity, n.Name)
+		v.Validated = false
+	}
+	if s.SessionAffinityConfig != nil && s.SessionAffinity != corev1.ServiceAffinityClientIP {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("SessionAffinityConfig needs the ClientIP SessionAffinity in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+}
+
+// validateServicePorts checks the named ports are unique valid port names and include a druid http port
+func (v *Validator) validateServicePorts(n *binaryomenv1alpha1.NodeSpec) {
+	names := map[string]bool{}
+	for _, port := range n.Service.Ports {
+		if errs := k8svalidation.IsValidPortName(port.Name); len(errs) > 0 {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Service port name [%s] in Druid Node Spec [%s]: %s\n", port.Name, n.Name, strings.Join(errs, ", "))
+			v.Validated = false
+		}
+		if names[port.Name] {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Duplicate Service port name [%s] in Druid Node Spec [%s]\n", port.Name, n.Name)
+			v.Validated = false
+		}
+		names[port.Name] = true
+		if port.Port == 0 || port.TargetPort == 0 {
+			v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Service port [%s] needs port and targetPort in Druid Node Spec [%s]\n", port.Name, n.Name)
+			v.Validated = false
+		}
+	}
+	if !names[binaryomenv1alpha1.PortPlaintext] && !names[binaryomenv1alpha1.PortTLS] {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Service ports need a plaintext or tls port in Druid Node Spec [%s]\n", n.Name)
+		v.Validated = false
+	}
+	if n.Ingress.Enabled && n.Ingress.TargetPort != "" && !names[n.Ingress.TargetPort] {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Ingress TargetPort [%s] is not a Service port in Druid Node Spec [%s]\n", n.Ingress.TargetPort, n.Name)
+		v.Validated = false
+	}
+}
+
+// validateMonitoring checks the monitor kind and that the metrics port is free on every node, overriding
+// druid.emitter only warns since the user may compose the prometheus emitter with others
+func (v *Validator) validateMonitoring(c *binaryomenv1alpha1.Druid) {
+	m := c.Spec.Monitoring
+	if m.Kind != "" && m.Kind != binaryomenv1alpha1.MonitorService && m.Kind != binaryomenv1alpha1.MonitorPod {
+		v.ErrorMessage = v.ErrorMessage + "Kind must be ServiceMonitor or PodMonitor in Druid Monitoring Spec\n"
+		v.Validated = false
+	}
+	if m.Port < 0 || m.Port > 65535 {
+		v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Invalid Port [%d] in Druid Monitoring Spec\n", m.Port)
+		v.Validated = false
+	}
+	if emitter, ok := nodes.GetCommonProperty(c, "druid.emitter"); ok && emitter != "prometheus" && emitter != "composing" {
+		v.WarningMessage = v.WarningMessage + fmt.Sprintf("druid.emitter [%s] in CommonRuntimeProperties overrides the prometheus emitter of the Druid Monitoring Spec\n", emitter)
+	}
+	if nodes.IsNetworkPolicyEnabled(c) && len(c.Spec.NetworkPolicy.Monitoring) == 0 && len(c.Spec.NetworkPolicy.Ingress) == 0 {
+		v.WarningMessage = v.WarningMessage + "NetworkPolicy denies scraping the metrics port, add prometheus to the Monitoring sources of the Druid NetworkPolicy Spec\n"
+	}
+
+	metricsPort := nodes.GetMetricsPortNumber(c)
+	for _, n := range c.Spec.Nodes {
+		if port, ok := nodes.GetServicePort(&n, binaryomenv1alpha1.PortMetrics); ok {
+			if port.TargetPort != metricsPort {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Service metrics TargetPort [%d] differs from the Druid Monitoring Port [%d] in Druid Node Spec [%s]\n", port.TargetPort, metricsPort, n.Name)
+				v.Validated = false
+			}
+			continue
+		}
+		for _, port := range nodes.GetServicePorts(&n) {
+			if port.TargetPort == metricsPort {
+				v.ErrorMessage = v.ErrorMessage + fmt.Sprintf("Metrics port conflicts with Service port [%s] in Druid Node Spec [%s]\n", port.Name, n.Name)
+				v.Validated = false
+			}
+		}
 	}
 }